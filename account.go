@@ -0,0 +1,311 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const AccountUserAdded = "user_added"
+const AccountUid0 = "uid0_account"
+const AccountPrivilegedMember = "privileged_member"
+const AccountPasswordChanged = "password_changed"
+const AccountNopasswd = "nopasswd_rule"
+
+// accountState represents the parsed account databases
+type accountState struct {
+	// uids maps user names to their UID
+	uids map[string]int
+	// passwords maps user names to the sha256 of their password hash, so no hash is kept in memory
+	passwords map[string]string
+	// members maps privileged group names to their members including users with it as primary group
+	members map[string]map[string]bool
+	// nopasswd maps sudoers rules without authentication to the file containing them
+	nopasswd map[string]string
+}
+
+// accountEvent represents a semantic change of the account databases
+type accountEvent struct {
+	event string
+	// name is the affected user, or the user specification for sudoers rules
+	name   string
+	detail string
+}
+
+// AccountTracker represents the user, group and sudoers tracking service
+type AccountTracker struct {
+	Config *Config
+	cached *accountState
+}
+
+// NewAccountTracker creates a new AccountTracker instance
+func NewAccountTracker(config *Config) *AccountTracker {
+	return &AccountTracker{Config: config}
+}
+
+// InitAccounts reads the account databases as baseline
+func (a *AccountTracker) InitAccounts(verbose, debug bool) {
+	state, err := a.readState(nil, debug)
+	if err != nil {
+		a.Config.logErr(err)
+		return
+	}
+	a.cached = &state
+	if verbose {
+		fmt.Println("Accounts at start: " + strconv.Itoa(len(state.uids)))
+		for group, members := range state.members {
+			fmt.Println("Group " + group + ": " + strings.Join(sortedKeys(members), ","))
+		}
+		for rule := range state.nopasswd {
+			fmt.Println("Rule without authentication: " + rule)
+		}
+	}
+}
+
+// TrackAccounts compares the account databases with the last state. Meant to be executed periodically. Returns the detected events.
+func (a *AccountTracker) TrackAccounts(noExec, debug bool) []accountEvent {
+	current, err := a.readState(a.cached, debug)
+	if err != nil {
+		a.Config.logErr(err)
+		if a.Config.ExecOnError {
+			a.Config.exec(debug, CalleeAccount, -1, noExec)
+		}
+		return nil
+	}
+	if a.cached == nil {
+		a.cached = &accountState{uids: map[string]int{}, passwords: map[string]string{}, members: map[string]map[string]bool{}, nopasswd: map[string]string{}}
+	}
+	events := a.cached.diff(current)
+	a.cached = &current
+
+	for _, event := range events {
+		a.Config.log("Account event " + event.event + ": " + event.name + " " + event.detail)
+		for _, target := range a.Config.AccountTrackingConfigs {
+			if target.matches(event) {
+				a.Config.execWithEnv(debug, CalleeAccount, target.CommandId, noExec,
+					"GOTRACK_ACCOUNT_EVENT="+event.event,
+					"GOTRACK_ACCOUNT_NAME="+event.name,
+					"GOTRACK_ACCOUNT_DETAIL="+event.detail,
+				)
+			}
+		}
+	}
+	return events
+}
+
+// matches checks if the target reacts to the event. Empty lists match everything.
+func (t AccountTarget) matches(event accountEvent) bool {
+	if len(t.Events) > 0 && !has(t.Events, event.event) {
+		return false
+	}
+	if len(t.Users) > 0 && !matchesAny(t.Users, event.name) {
+		return false
+	}
+	return true
+}
+
+// diff returns the events leading from the state s to current
+func (s *accountState) diff(current accountState) []accountEvent {
+	var events []accountEvent
+	for _, name := range sortedKeys(current.uids) {
+		uid := current.uids[name]
+		previous, known := s.uids[name]
+		if !known {
+			events = append(events, accountEvent{event: AccountUserAdded, name: name, detail: "uid " + strconv.Itoa(uid)})
+		}
+		if uid == 0 && (!known || previous != 0) {
+			events = append(events, accountEvent{event: AccountUid0, name: name, detail: "uid 0"})
+		}
+	}
+	for _, group := range sortedKeys(current.members) {
+		for _, name := range sortedKeys(current.members[group]) {
+			if !s.members[group][name] {
+				events = append(events, accountEvent{event: AccountPrivilegedMember, name: name, detail: "group " + group})
+			}
+		}
+	}
+	for _, name := range sortedKeys(current.passwords) {
+		// Only existing users, new users are reported as added
+		if previous, known := s.passwords[name]; known && previous != current.passwords[name] {
+			events = append(events, accountEvent{event: AccountPasswordChanged, name: name})
+		}
+	}
+	for _, rule := range sortedKeys(current.nopasswd) {
+		if _, known := s.nopasswd[rule]; !known {
+			events = append(events, accountEvent{event: AccountNopasswd, name: strings.Fields(rule)[0], detail: current.nopasswd[rule] + ": " + rule})
+		}
+	}
+	return events
+}
+
+// readState parses passwd, shadow, group and sudoers. Only an unreadable passwd file is an error, as the others may require root.
+// Sections of the other files that can not be read are taken from previous, so they are not reported as new once readable again.
+func (a *AccountTracker) readState(previous *accountState, debug bool) (accountState, error) {
+	state := accountState{uids: map[string]int{}, passwords: map[string]string{}, members: map[string]map[string]bool{}, nopasswd: map[string]string{}}
+	if previous == nil {
+		previous = &accountState{}
+	}
+
+	passwd, err := readColonFile(a.Config.AccountPasswdPath)
+	if err != nil {
+		return state, err
+	}
+	primaryGroups := make(map[string]string)
+	for _, fields := range passwd {
+		if len(fields) < 4 {
+			continue
+		}
+		if uid, err := strconv.Atoi(fields[2]); err == nil {
+			state.uids[fields[0]] = uid
+		}
+		primaryGroups[fields[0]] = fields[3]
+	}
+
+	if group, err := readColonFile(a.Config.AccountGroupPath); err == nil {
+		for _, fields := range group {
+			if len(fields) < 4 || !has(a.Config.AccountPrivilegedGroups, fields[0]) {
+				continue
+			}
+			members := make(map[string]bool)
+			for _, member := range strings.Split(fields[3], ",") {
+				if len(member) > 0 {
+					members[member] = true
+				}
+			}
+			for name, gid := range primaryGroups {
+				if gid == fields[2] {
+					members[name] = true
+				}
+			}
+			state.members[fields[0]] = members
+		}
+	} else {
+		a.Config.logErr(err)
+		for group, members := range previous.members {
+			state.members[group] = members
+		}
+	}
+
+	if shadow, err := readColonFile(a.Config.AccountShadowPath); err == nil {
+		for _, fields := range shadow {
+			if len(fields) < 2 {
+				continue
+			}
+			sum := sha256.Sum256([]byte(fields[1]))
+			state.passwords[fields[0]] = hex.EncodeToString(sum[:])
+		}
+	} else {
+		a.Config.logErr(err)
+		for name, password := range previous.passwords {
+			state.passwords[name] = password
+		}
+	}
+
+	files, failed := a.sudoersFiles(debug)
+	for _, path := range files {
+		rules, err := readNopasswdRules(path)
+		if err != nil {
+			a.Config.logErr(err)
+			failed = append(failed, path)
+			continue
+		}
+		for _, rule := range rules {
+			state.nopasswd[rule] = path
+		}
+	}
+	// Keep the rules of files and directories that could not be read
+	for rule, path := range previous.nopasswd {
+		if has(failed, path) || has(failed, filepath.Dir(path)) {
+			state.nopasswd[rule] = path
+		}
+	}
+	return state, nil
+}
+
+// sudoersFiles returns the configured sudoers files and the files in configured directories, and the configured paths that could not be read.
+// Missing paths are skipped. Like sudo, files in directories ending with "~" or containing "." are skipped.
+func (a *AccountTracker) sudoersFiles(debug bool) ([]string, []string) {
+	var files, failed []string
+	for _, path := range a.Config.AccountSudoersPaths {
+		info, err := os.Stat(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				a.Config.logErr(err)
+				failed = append(failed, path)
+			} else if debug {
+				a.Config.logErr(err)
+			}
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			a.Config.logErr(err)
+			failed = append(failed, path)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasSuffix(entry.Name(), "~") || strings.Contains(entry.Name(), ".") {
+				continue
+			}
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	return files, failed
+}
+
+// readColonFile reads a file with colon separated fields like /etc/passwd, skipping empty lines and comments
+func readColonFile(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines [][]string
+	for _, line := range strings.Split(string(data), "\n") {
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.Split(line, ":"))
+	}
+	return lines, nil
+}
+
+// readNopasswdRules returns the rules of a sudoers file granting access without authentication with normalized whitespace
+func readNopasswdRules(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Lines ending with a backslash are continued
+	content := strings.ReplaceAll(string(data), "\\\n", " ")
+	var rules []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		// "#include" is a directive, but is not relevant here
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "NOPASSWD") || strings.Contains(line, "!authenticate") {
+			rules = append(rules, line)
+		}
+	}
+	return rules, nil
+}
+
+// sortedKeys returns the keys of the map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
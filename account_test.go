@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAccountTracker_TrackAccounts(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) func() {
+		return func() {
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700); err != nil {
+				t.Fatalf("Error creating directory: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+				t.Fatalf("Error writing %s: %v", name, err)
+			}
+		}
+	}
+	config := NewConfig()
+	config.LogFile = ""
+	config.AccountPasswdPath = filepath.Join(dir, "passwd")
+	config.AccountShadowPath = filepath.Join(dir, "shadow")
+	config.AccountGroupPath = filepath.Join(dir, "group")
+	config.AccountSudoersPaths = []string{filepath.Join(dir, "sudoers"), filepath.Join(dir, "sudoers.d")}
+	config.AccountTrackingConfigs = []AccountTarget{{Events: []string{AccountUid0}, CommandId: -1}}
+	write("passwd", "root:x:0:0:root:/root:/bin/bash\nalice:x:1000:1000::/home/alice:/bin/bash\n")()
+	write("shadow", "root:$6$old:19000::::::\nalice:$6$alice:19000::::::\n")()
+	write("group", "root:x:0:\nsudo:x:27:\nalice:x:1000:\n")()
+	write("sudoers", "root ALL=(ALL:ALL) ALL\n# alice ALL=(ALL) NOPASSWD: ALL\n")()
+
+	a := NewAccountTracker(config)
+	a.InitAccounts(false, false)
+
+	steps := []struct {
+		name   string
+		action func()
+		want   []accountEvent
+	}{
+		{name: "Unchanged", action: func() {}, want: nil},
+		{name: "User added", action: write("passwd", "root:x:0:0:root:/root:/bin/bash\nalice:x:1000:1000::/home/alice:/bin/bash\nbob:x:1001:1001::/home/bob:/bin/sh\n"),
+			want: []accountEvent{{event: AccountUserAdded, name: "bob", detail: "uid 1001"}}},
+		{name: "UID 0 account", action: write("passwd", "root:x:0:0:root:/root:/bin/bash\nalice:x:1000:1000::/home/alice:/bin/bash\nbob:x:0:1001::/home/bob:/bin/sh\n"),
+			want: []accountEvent{{event: AccountUid0, name: "bob", detail: "uid 0"}}},
+		{name: "Added to sudo", action: write("group", "root:x:0:\nsudo:x:27:alice\nalice:x:1000:\n"),
+			want: []accountEvent{{event: AccountPrivilegedMember, name: "alice", detail: "group sudo"}}},
+		{name: "Password changed", action: write("shadow", "root:$6$new:19000::::::\nalice:$6$alice:19000::::::\n"),
+			want: []accountEvent{{event: AccountPasswordChanged, name: "root"}}},
+		{name: "NOPASSWD rule", action: write("sudoers.d/alice", "alice ALL=(ALL) \\\n  NOPASSWD: ALL\n"),
+			want: []accountEvent{{event: AccountNopasswd, name: "alice", detail: filepath.Join(dir, "sudoers.d", "alice") + ": alice ALL=(ALL) NOPASSWD: ALL"}}},
+		{name: "Skipped sudoers file", action: write("sudoers.d/bob~", "bob ALL=(ALL) NOPASSWD: ALL\n"), want: nil},
+		{name: "Unreadable files keep the previous state", action: func() {
+			for _, name := range []string{"group", "shadow", "sudoers.d/alice"} {
+				if err := os.Remove(filepath.Join(dir, name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Reading a directory or a dangling link fails
+			if err := os.Mkdir(filepath.Join(dir, "group"), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Mkdir(filepath.Join(dir, "shadow"), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "sudoers.d", "alice")); err != nil {
+				t.Fatal(err)
+			}
+		}, want: nil},
+		{name: "Readable again", action: func() {
+			for _, name := range []string{"group", "shadow", "sudoers.d/alice"} {
+				if err := os.Remove(filepath.Join(dir, name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			write("group", "root:x:0:\nsudo:x:27:alice\nalice:x:1000:\n")()
+			write("shadow", "root:$6$new:19000::::::\nalice:$6$alice:19000::::::\n")()
+			write("sudoers.d/alice", "alice ALL=(ALL) \\\n  NOPASSWD: ALL\n")()
+		}, want: nil},
+	}
+	for _, step := range steps {
+		step.action()
+		if got := a.TrackAccounts(true, false); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: TrackAccounts() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestAccountTarget_matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		target AccountTarget
+		event  accountEvent
+		want   bool
+	}{
+		{name: "Empty target", target: AccountTarget{}, event: accountEvent{event: AccountUserAdded, name: "bob"}, want: true},
+		{name: "Event matching", target: AccountTarget{Events: []string{AccountUid0}}, event: accountEvent{event: AccountUid0, name: "bob"}, want: true},
+		{name: "Event not matching", target: AccountTarget{Events: []string{AccountUid0}}, event: accountEvent{event: AccountUserAdded, name: "bob"}, want: false},
+		{name: "User matching", target: AccountTarget{Users: []string{"ro*"}}, event: accountEvent{event: AccountPasswordChanged, name: "root"}, want: true},
+		{name: "User not matching", target: AccountTarget{Users: []string{"root"}}, event: accountEvent{event: AccountPasswordChanged, name: "alice"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.matches(tt.event); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
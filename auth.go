@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// authParser represents a built-in parser for authentication failures of a service
+type authParser struct {
+	service string
+	pattern *regexp.Regexp
+}
+
+// authParsers holds the built-in parsers. Each failed attempt is matched by exactly one line.
+var authParsers = []authParser{
+	{service: "sshd", pattern: regexp.MustCompile(`sshd(?:-session)?\[\d+\]: Failed \S+ for (?:invalid user )?(?P<user>\S*) from (?P<host>\S+)`)},
+	{service: "sudo", pattern: regexp.MustCompile(`sudo(?:\[\d+\])?: pam_unix\(sudo(?:-i)?:auth\): authentication failure;.*\blogname=(?P<user>\S*)`)},
+	{service: "su", pattern: regexp.MustCompile(`su(?:\[\d+\])?: pam_unix\(su(?:-l)?:auth\): authentication failure;.*\blogname=(?P<user>\S*)`)},
+	{service: "login", pattern: regexp.MustCompile(`login(?:\[\d+\])?: FAILED LOGIN \(\d+\)(?: on '[^']*')?(?: from '(?P<host>[^']*)')? FOR '(?P<user>[^']*)'`)},
+}
+
+// authFailure represents a failed authentication found in a log line
+type authFailure struct {
+	service string
+	user    string
+	host    string
+	line    string
+}
+
+// slidingWindow counts events within a time window
+type slidingWindow struct {
+	window time.Duration
+	events []time.Time
+}
+
+// add registers an event and returns the number of events within the window. Events never expire if window is not positive.
+func (s *slidingWindow) add(now time.Time) int {
+	s.events = append(s.events, now)
+	if s.window > 0 {
+		expired := 0
+		for _, event := range s.events {
+			if now.Sub(event) < s.window {
+				break
+			}
+			expired++
+		}
+		s.events = s.events[expired:]
+	}
+	return len(s.events)
+}
+
+// reset removes all events
+func (s *slidingWindow) reset() {
+	s.events = nil
+}
+
+// AuthTracker represents the authentication failure tracking service
+type AuthTracker struct {
+	Config    *Config
+	followers []*fileFollower
+	windows   []*slidingWindow
+	// unreadable is set while no auth log could be read, so the error is only reported once
+	unreadable bool
+}
+
+// NewAuthTracker creates a new AuthTracker instance. Existing log content is skipped.
+func NewAuthTracker(config *Config) *AuthTracker {
+	a := &AuthTracker{Config: config}
+	for _, path := range config.AuthLogPaths {
+		a.followers = append(a.followers, newFileFollower(path))
+	}
+	for _, target := range config.AuthTrackingConfigs {
+		a.windows = append(a.windows, &slidingWindow{window: target.Window})
+	}
+	return a
+}
+
+// TrackAuthFailures reads new lines of the auth logs and counts failures. Meant to be executed periodically. Returns the number of failures found.
+func (a *AuthTracker) TrackAuthFailures(noExec, debug bool) uint {
+	var counter uint = 0
+	readable := 0
+	var lastErr error
+	for _, follower := range a.followers {
+		lines, err := follower.readLines()
+		if err != nil {
+			// Usually only one of the configured logs exists. Missing logs are waited for, e.g. on journald only hosts.
+			if debug {
+				a.Config.logErr(err)
+			}
+			if !os.IsNotExist(err) {
+				lastErr = err
+			}
+			continue
+		}
+		readable++
+		for _, line := range lines {
+			if failure, ok := parseAuthFailure(line); ok {
+				counter++
+				a.handleFailure(noExec, debug, failure, time.Now())
+			}
+		}
+	}
+	if readable == 0 && lastErr != nil {
+		if !a.unreadable {
+			a.unreadable = true
+			a.Config.log("No auth log readable")
+			a.Config.logErr(lastErr)
+			if a.Config.ExecOnError {
+				a.Config.exec(debug, CalleeAuth, -1, noExec)
+			}
+		}
+	} else if readable > 0 && a.unreadable {
+		a.unreadable = false
+		a.Config.log("Auth log readable again")
+	}
+	return counter
+}
+
+// handleFailure counts the failure for all matching rules and triggers if the threshold is reached
+func (a *AuthTracker) handleFailure(noExec, debug bool, failure authFailure, now time.Time) {
+	if debug {
+		a.Config.log("Authentication failure: " + failure.line)
+	}
+	for i, target := range a.Config.AuthTrackingConfigs {
+		if len(target.Services) > 0 && !has(target.Services, failure.service) {
+			continue
+		}
+		count := a.windows[i].add(now)
+		if count >= target.Threshold {
+			a.Config.log("Authentication failures exceeded threshold (" + strconv.Itoa(count) + ") for service: " + failure.service + " User: " + failure.user)
+			a.windows[i].reset()
+			a.Config.execWithEnv(debug, CalleeAuth, target.CommandId, noExec,
+				"GOTRACK_AUTH_SERVICE="+failure.service,
+				"GOTRACK_AUTH_USER="+failure.user,
+				"GOTRACK_AUTH_HOST="+failure.host,
+				"GOTRACK_AUTH_COUNT="+strconv.Itoa(count),
+				"GOTRACK_AUTH_LINE="+failure.line,
+			)
+		}
+	}
+}
+
+// parseAuthFailure checks a log line against all built-in parsers
+func parseAuthFailure(line string) (authFailure, bool) {
+	for _, parser := range authParsers {
+		match := parser.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		failure := authFailure{service: parser.service, line: line}
+		if i := parser.pattern.SubexpIndex("user"); i >= 0 {
+			failure.user = match[i]
+		}
+		if i := parser.pattern.SubexpIndex("host"); i >= 0 {
+			failure.host = match[i]
+		}
+		return failure, true
+	}
+	return authFailure{}, false
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseAuthFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want authFailure
+		ok   bool
+	}{
+		{
+			name: "sshd password",
+			line: "Oct 19 10:00:00 host sshd[1234]: Failed password for alice from 192.0.2.10 port 50000 ssh2",
+			want: authFailure{service: "sshd", user: "alice", host: "192.0.2.10"},
+			ok:   true,
+		},
+		{
+			name: "sshd invalid user",
+			line: "Oct 19 10:00:00 host sshd[1234]: Failed password for invalid user admin from 192.0.2.11 port 50000 ssh2",
+			want: authFailure{service: "sshd", user: "admin", host: "192.0.2.11"},
+			ok:   true,
+		},
+		{
+			name: "sudo",
+			line: "Oct 19 10:00:00 host sudo: pam_unix(sudo:auth): authentication failure; logname=alice uid=1000 euid=0 tty=/dev/pts/0 ruser=alice rhost=  user=alice",
+			want: authFailure{service: "sudo", user: "alice"},
+			ok:   true,
+		},
+		{
+			name: "su",
+			line: "Oct 19 10:00:00 host su[99]: pam_unix(su:auth): authentication failure; logname=bob uid=1001 euid=0 tty=pts/1 ruser=bob rhost=  user=root",
+			want: authFailure{service: "su", user: "bob"},
+			ok:   true,
+		},
+		{
+			name: "login",
+			line: "Oct 19 10:00:00 host login[42]: FAILED LOGIN (1) on '/dev/tty1' FOR 'root', Authentication failure",
+			want: authFailure{service: "login", user: "root"},
+			ok:   true,
+		},
+		{
+			name: "Accepted login",
+			line: "Oct 19 10:00:00 host sshd[1234]: Accepted publickey for alice from 192.0.2.10 port 50000 ssh2",
+			ok:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAuthFailure(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseAuthFailure() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok {
+				tt.want.line = tt.line
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAuthFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_slidingWindow_add(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	s := &slidingWindow{window: time.Minute}
+	if got := s.add(start); got != 1 {
+		t.Errorf("add() = %v, want 1", got)
+	}
+	if got := s.add(start.Add(30 * time.Second)); got != 2 {
+		t.Errorf("add() = %v, want 2", got)
+	}
+	if got := s.add(start.Add(70 * time.Second)); got != 2 {
+		t.Errorf("add() = %v, want 2 after expiry of first event", got)
+	}
+	s.reset()
+	if got := s.add(start.Add(80 * time.Second)); got != 1 {
+		t.Errorf("add() = %v, want 1 after reset", got)
+	}
+
+	unlimited := &slidingWindow{}
+	unlimited.add(start)
+	if got := unlimited.add(start.Add(24 * time.Hour)); got != 2 {
+		t.Errorf("add() = %v, want 2 without window", got)
+	}
+}
+
+func TestAuthTracker_TrackAuthFailures(t *testing.T) {
+	dir := t.TempDir()
+	config := NewConfig()
+	config.LogFile = ""
+	config.AuthLogPaths = []string{filepath.Join(dir, "auth.log"), filepath.Join(dir, "secure")}
+	config.AuthTrackingConfigs = []AuthTarget{{Services: []string{"sudo"}, Threshold: 2, Window: time.Minute, CommandId: -1}}
+	appendToFile(t, config.AuthLogPaths[0], "Oct 19 09:00:00 host sudo: pam_unix(sudo:auth): authentication failure; logname=old uid=1000\n")
+
+	a := NewAuthTracker(config)
+	if got := a.TrackAuthFailures(true, false); got != 0 {
+		t.Errorf("TrackAuthFailures() = %v, want 0 for old content", got)
+	}
+	appendToFile(t, config.AuthLogPaths[0], "Oct 19 10:00:00 host sudo: pam_unix(sudo:auth): authentication failure; logname=alice uid=1000\n"+
+		"Oct 19 10:00:01 host sshd[1]: Failed password for root from 192.0.2.1 port 22 ssh2\n")
+	if got := a.TrackAuthFailures(true, false); got != 2 {
+		t.Errorf("TrackAuthFailures() = %v, want 2", got)
+	}
+	if got := len(a.windows[0].events); got != 1 {
+		t.Errorf("Counted failures = %v, want 1 for sudo only rule", got)
+	}
+	appendToFile(t, config.AuthLogPaths[0], "Oct 19 10:00:02 host sudo: pam_unix(sudo:auth): authentication failure; logname=alice uid=1000\n")
+	a.TrackAuthFailures(true, false)
+	if got := len(a.windows[0].events); got != 0 {
+		t.Errorf("Counted failures = %v, want 0 after reaching threshold", got)
+	}
+}
+
+func TestAuthTracker_TrackAuthFailures_unreadable(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "executed")
+	config := NewConfig()
+	config.LogFile = ""
+	config.ExecOnError = true
+	config.Commands = []Command{{Command: "touch", Args: []string{marker}, Auth: true, Id: -1}}
+	config.AuthLogPaths = []string{filepath.Join(dir, "auth.log"), filepath.Join(dir, "secure")}
+
+	// Missing logs are waited for
+	a := NewAuthTracker(config)
+	a.TrackAuthFailures(false, false)
+	if fileExists(marker) {
+		t.Fatal("TrackAuthFailures() executed commands for missing logs")
+	}
+
+	// Unreadable logs are reported once
+	if err := os.Mkdir(config.AuthLogPaths[1], 0700); err != nil {
+		t.Fatal(err)
+	}
+	a.TrackAuthFailures(false, false)
+	if !fileExists(marker) {
+		t.Fatal("TrackAuthFailures() did not execute commands for unreadable logs")
+	}
+	if err := os.Remove(marker); err != nil {
+		t.Fatal(err)
+	}
+	a.TrackAuthFailures(false, false)
+	if fileExists(marker) {
+		t.Error("TrackAuthFailures() executed commands again for unreadable logs")
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const bluetoothSysfsPath = "/sys/class/bluetooth"
+const bluezStatePath = "/var/lib/bluetooth"
+
+const BluetoothAdapterAdded = "adapter_added"
+const BluetoothPowered = "powered"
+const BluetoothPaired = "paired"
+const BluetoothConnected = "connected"
+
+// HCIGETDEVINFO and HCIGETCONNLIST ioctls of raw HCI sockets
+const hciGetDevInfo = 0x800448d3
+const hciGetConnList = 0x800448d4
+
+// hciMaxConnections is the number of connections requested per adapter
+const hciMaxConnections = 32
+
+// bluetoothAddress matches the MAC address of a Bluetooth device
+var bluetoothAddress = regexp.MustCompile(`^([0-9A-F]{2}:){5}[0-9A-F]{2}$`)
+
+// bluetoothState represents the state of all adapters and devices
+type bluetoothState struct {
+	// adapters maps the adapter name like "hci0" to its powered state
+	adapters  map[string]bool
+	paired    map[string]bool
+	connected map[string]bool
+}
+
+// hciAdapter represents the kernel state of an adapter
+type hciAdapter struct {
+	// up is the HCI_UP flag of the kernel
+	up bool
+}
+
+// bluetoothEvent represents a change of the Bluetooth state
+type bluetoothEvent struct {
+	event  string
+	device string
+}
+
+// BluetoothTracker represents the Bluetooth tracking service
+type BluetoothTracker struct {
+	Config *Config
+	cached bluetoothState
+	// sysfsRoot is the directory holding the adapters
+	sysfsRoot string
+	// bluezRoot is the directory holding the BlueZ state files
+	bluezRoot string
+	// adapterInfo returns the kernel state of an adapter like "hci0"
+	adapterInfo func(adapter string) (hciAdapter, error)
+	// connections maps the handles of the connections of an adapter to the device addresses
+	connections func(adapter string) (map[string]string, error)
+}
+
+// NewBluetoothTracker creates a new BluetoothTracker instance
+func NewBluetoothTracker(config *Config) *BluetoothTracker {
+	return &BluetoothTracker{
+		Config:      config,
+		sysfsRoot:   bluetoothSysfsPath,
+		bluezRoot:   bluezStatePath,
+		adapterInfo: readHCIAdapter,
+		connections: readHCIConnections,
+	}
+}
+
+// InitBluetooth initializes the state of adapters and devices
+func (b *BluetoothTracker) InitBluetooth(verbose, debug bool) {
+	b.cached = b.readState(debug)
+	if verbose {
+		fmt.Println("Bluetooth at start:\nAdapter\tPowered")
+		for name, powered := range b.cached.adapters {
+			fmt.Println(name + "\t" + strconv.FormatBool(powered))
+		}
+		for device := range b.cached.paired {
+			fmt.Println("Paired: " + device)
+		}
+		for device := range b.cached.connected {
+			fmt.Println("Connected: " + device)
+		}
+	}
+}
+
+// TrackBluetooth tracks Bluetooth adapters and devices. Meant to be executed periodically. Returns the detected events.
+func (b *BluetoothTracker) TrackBluetooth(noExec, debug bool) []bluetoothEvent {
+	current := b.readState(debug)
+	if b.cached.adapters == nil {
+		b.cached = bluetoothState{adapters: map[string]bool{}, paired: map[string]bool{}, connected: map[string]bool{}}
+	}
+
+	var events []bluetoothEvent
+	for name, powered := range current.adapters {
+		cachedPowered, known := b.cached.adapters[name]
+		if !known {
+			events = append(events, bluetoothEvent{event: BluetoothAdapterAdded, device: name})
+		}
+		if powered && !cachedPowered {
+			events = append(events, bluetoothEvent{event: BluetoothPowered, device: name})
+		}
+	}
+	for device := range current.paired {
+		if !b.cached.paired[device] {
+			events = append(events, bluetoothEvent{event: BluetoothPaired, device: device})
+		}
+	}
+	for device := range current.connected {
+		if !b.cached.connected[device] {
+			events = append(events, bluetoothEvent{event: BluetoothConnected, device: device})
+		}
+	}
+	b.cached = current
+
+	for _, event := range events {
+		b.Config.log("Bluetooth " + event.event + ": " + event.device)
+		for _, target := range b.Config.BluetoothTrackingConfigs {
+			if target.matches(event) {
+				b.Config.execWithEnv(debug, CalleeBluetooth, target.CommandId, noExec, "GOTRACK_BLUETOOTH_EVENT="+event.event, "GOTRACK_BLUETOOTH_DEVICE="+event.device)
+			}
+		}
+	}
+	return events
+}
+
+// matches checks if the rule reacts to the event
+func (t BluetoothTarget) matches(event bluetoothEvent) bool {
+	switch event.event {
+	case BluetoothAdapterAdded:
+		return t.OnAdapterAdded
+	case BluetoothPowered:
+		return t.OnPowered
+	case BluetoothPaired:
+		return t.OnPaired && !has(t.AllowedDevices, event.device)
+	case BluetoothConnected:
+		return t.OnConnected && !has(t.AllowedDevices, event.device)
+	}
+	return false
+}
+
+// readState reads adapters and their connections from sysfs and paired devices from BlueZ
+func (b *BluetoothTracker) readState(debug bool) bluetoothState {
+	state := bluetoothState{adapters: map[string]bool{}, paired: map[string]bool{}, connected: map[string]bool{}}
+
+	entries, err := os.ReadDir(b.sysfsRoot)
+	if err != nil && debug {
+		// No adapter present
+		b.Config.logErr(err)
+	}
+	// links maps adapters to the handles of their connections
+	links := make(map[string][]string)
+	for _, entry := range entries {
+		// Connections are listed as "hci0:12" with the connection handle
+		if adapter, handle, found := strings.Cut(entry.Name(), ":"); found {
+			links[adapter] = append(links[adapter], handle)
+			continue
+		}
+		state.adapters[entry.Name()] = b.isPowered(entry.Name(), debug)
+	}
+
+	// BlueZ stores paired devices as <adapter address>/<device address>/info with a key section
+	infos, _ := filepath.Glob(filepath.Join(b.bluezRoot, "*", "*", "info"))
+	for _, info := range infos {
+		device := filepath.Base(filepath.Dir(info))
+		if !bluetoothAddress.MatchString(device) {
+			continue
+		}
+		if data, err := os.ReadFile(info); err == nil && (strings.Contains(string(data), "[LinkKey]") || strings.Contains(string(data), "[LongTermKey]")) {
+			state.paired[device] = true
+		}
+	}
+
+	for adapter, handles := range links {
+		addresses, err := b.connections(adapter)
+		if err != nil {
+			b.Config.logErr(err)
+		}
+		for _, handle := range handles {
+			// Connections that can not be resolved are reported by their link, so they are never allowed
+			if address, ok := addresses[handle]; ok {
+				state.connected[address] = true
+			} else {
+				state.connected[adapter+":"+handle] = true
+			}
+		}
+	}
+	return state
+}
+
+// isPowered checks if the adapter is not blocked by rfkill and up according to the kernel. The kernel state is used
+// instead of the BlueZ settings, as adapters can be powered outside of bluetoothd.
+func (b *BluetoothTracker) isPowered(adapter string, debug bool) bool {
+	states, _ := filepath.Glob(filepath.Join(b.sysfsRoot, adapter, "rfkill*", "state"))
+	for _, path := range states {
+		// 1 means unblocked
+		if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) != "1" {
+			return false
+		}
+	}
+	info, err := b.adapterInfo(adapter)
+	if err != nil {
+		if debug {
+			b.Config.logErr(err)
+		}
+		return false
+	}
+	return info.up
+}
+
+// readHCIAdapter reads the HCI_UP flag of an adapter like "hci0" from the kernel
+func readHCIAdapter(adapter string) (hciAdapter, error) {
+	// struct hci_dev_info starts with dev_id, name[8], bdaddr[6] and flags
+	buffer := make([]byte, 128)
+	if err := hciIoctl(adapter, hciGetDevInfo, buffer); err != nil {
+		return hciAdapter{}, err
+	}
+	return hciAdapter{up: binary.NativeEndian.Uint32(buffer[16:20])&1 != 0}, nil
+}
+
+// readHCIConnections reads the connections of an adapter like "hci0" from the kernel. Returns the device addresses by connection handle.
+func readHCIConnections(adapter string) (map[string]string, error) {
+	// struct hci_conn_list_req holds dev_id and conn_num followed by hci_conn_info entries of 16 bytes
+	buffer := make([]byte, 4+16*hciMaxConnections)
+	binary.NativeEndian.PutUint16(buffer[2:], hciMaxConnections)
+	if err := hciIoctl(adapter, hciGetConnList, buffer); err != nil {
+		return nil, err
+	}
+	connections := make(map[string]string)
+	count := min(int(binary.NativeEndian.Uint16(buffer[2:])), hciMaxConnections)
+	for i := 0; i < count; i++ {
+		// Each entry starts with handle and bdaddr[6]
+		entry := buffer[4+16*i:]
+		connections[strconv.Itoa(int(binary.NativeEndian.Uint16(entry)))] = bluetoothAddressString(entry[2:8])
+	}
+	return connections, nil
+}
+
+// hciIoctl executes the request for the adapter on a raw HCI socket. The buffer has to start with the device id, which is set here.
+func hciIoctl(adapter string, request uintptr, buffer []byte) error {
+	id, err := strconv.Atoi(strings.TrimPrefix(adapter, "hci"))
+	if err != nil {
+		return fmt.Errorf("invalid Bluetooth adapter %s: %w", adapter, err)
+	}
+	binary.NativeEndian.PutUint16(buffer, uint16(id))
+	fd, err := unix.Socket(unix.AF_BLUETOOTH, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.BTPROTO_HCI)
+	if err != nil {
+		return err
+	}
+	defer func(fd int) {
+		_ = unix.Close(fd)
+	}(fd)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), request, uintptr(unsafe.Pointer(&buffer[0]))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// bluetoothAddressString formats a bdaddr, which the kernel stores in reversed byte order
+func bluetoothAddressString(bdaddr []byte) string {
+	parts := make([]string, len(bdaddr))
+	for i, b := range bdaddr {
+		parts[len(bdaddr)-1-i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeBluetoothFile writes content to path and creates missing directories
+func writeBluetoothFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("Error creating directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Error writing file: %v", err)
+	}
+}
+
+func TestBluetoothTracker_TrackBluetooth(t *testing.T) {
+	sysfs := t.TempDir()
+	bluez := t.TempDir()
+	config := NewConfig()
+	config.LogFile = ""
+	config.BluetoothTrackingConfigs = []BluetoothTarget{{OnPowered: true, OnConnected: true, AllowedDevices: []string{"AA:BB:CC:DD:EE:FF"}, CommandId: -1}}
+	writeBluetoothFile(t, filepath.Join(sysfs, "hci0", "rfkill0", "state"), "0\n")
+	writeBluetoothFile(t, filepath.Join(bluez, "00:11:22:33:44:55", "settings"), "[General]\nPowered=true\n")
+
+	up := map[string]bool{"hci0": true}
+	connections := map[string]string{}
+	b := NewBluetoothTracker(config)
+	b.sysfsRoot = sysfs
+	b.bluezRoot = bluez
+	b.adapterInfo = func(adapter string) (hciAdapter, error) {
+		return hciAdapter{up: up[adapter]}, nil
+	}
+	b.connections = func(adapter string) (map[string]string, error) {
+		return connections, nil
+	}
+	b.InitBluetooth(false, false)
+
+	steps := []struct {
+		name   string
+		action func()
+		want   []bluetoothEvent
+	}{
+		{name: "Unchanged", action: func() {}, want: nil},
+		{name: "Unblocked", action: func() {
+			writeBluetoothFile(t, filepath.Join(sysfs, "hci0", "rfkill0", "state"), "1\n")
+		}, want: []bluetoothEvent{{event: BluetoothPowered, device: "hci0"}}},
+		{name: "Powered off outside BlueZ", action: func() { up["hci0"] = false }, want: nil},
+		{name: "Powered on outside BlueZ", action: func() {
+			writeBluetoothFile(t, filepath.Join(bluez, "00:11:22:33:44:55", "settings"), "[General]\nPowered=false\n")
+			up["hci0"] = true
+		}, want: []bluetoothEvent{{event: BluetoothPowered, device: "hci0"}}},
+		{name: "Paired", action: func() {
+			writeBluetoothFile(t, filepath.Join(bluez, "00:11:22:33:44:55", "AA:BB:CC:DD:EE:FF", "info"), "[General]\nName=Headset\n\n[LinkKey]\nKey=0\n")
+		}, want: []bluetoothEvent{{event: BluetoothPaired, device: "AA:BB:CC:DD:EE:FF"}}},
+		{name: "Unpaired device ignored", action: func() {
+			writeBluetoothFile(t, filepath.Join(bluez, "00:11:22:33:44:55", "11:11:11:11:11:11", "info"), "[General]\nName=Nearby\n")
+		}, want: nil},
+		{name: "Connected", action: func() {
+			connections["12"] = "AA:BB:CC:DD:EE:FF"
+			writeBluetoothFile(t, filepath.Join(sysfs, "hci0:12", "uevent"), "DEVTYPE=link\n")
+		}, want: []bluetoothEvent{{event: BluetoothConnected, device: "AA:BB:CC:DD:EE:FF"}}},
+		{name: "Unresolved connection", action: func() {
+			writeBluetoothFile(t, filepath.Join(sysfs, "hci0:13", "uevent"), "DEVTYPE=link\n")
+		}, want: []bluetoothEvent{{event: BluetoothConnected, device: "hci0:13"}}},
+		{name: "Adapter added", action: func() { writeBluetoothFile(t, filepath.Join(sysfs, "hci1", "name"), "") }, want: []bluetoothEvent{{event: BluetoothAdapterAdded, device: "hci1"}}},
+		{name: "Powered without BlueZ setting", action: func() { up["hci1"] = true }, want: []bluetoothEvent{{event: BluetoothPowered, device: "hci1"}}},
+	}
+	for _, step := range steps {
+		step.action()
+		if got := b.TrackBluetooth(true, false); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: TrackBluetooth() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func Test_bluetoothAddressString(t *testing.T) {
+	if got := bluetoothAddressString([]byte{0xff, 0xee, 0xdd, 0xcc, 0xbb, 0x0a}); got != "0A:BB:CC:DD:EE:FF" {
+		t.Errorf("bluetoothAddressString() = %v, want 0A:BB:CC:DD:EE:FF", got)
+	}
+}
+
+func TestBluetoothTarget_matches(t *testing.T) {
+	target := BluetoothTarget{OnAdapterAdded: true, OnConnected: true, AllowedDevices: []string{"AA:BB:CC:DD:EE:FF"}}
+	tests := []struct {
+		name  string
+		event bluetoothEvent
+		want  bool
+	}{
+		{name: "Adapter added", event: bluetoothEvent{event: BluetoothAdapterAdded, device: "hci0"}, want: true},
+		{name: "Powered disabled", event: bluetoothEvent{event: BluetoothPowered, device: "hci0"}, want: false},
+		{name: "Paired disabled", event: bluetoothEvent{event: BluetoothPaired, device: "11:22:33:44:55:66"}, want: false},
+		{name: "Unknown device connected", event: bluetoothEvent{event: BluetoothConnected, device: "11:22:33:44:55:66"}, want: true},
+		{name: "Allowed device connected", event: bluetoothEvent{event: BluetoothConnected, device: "AA:BB:CC:DD:EE:FF"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := target.matches(tt.event); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+const cmdlinePath = "/proc/cmdline"
+const osReleasePath = "/proc/sys/kernel/osrelease"
+const secureBootPath = "/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// BootChecker represents the boot integrity check at startup
+type BootChecker struct {
+	Config         *Config
+	cmdlinePath    string
+	osReleasePath  string
+	secureBootPath string
+}
+
+// NewBootChecker creates a new BootChecker instance
+func NewBootChecker(config *Config) *BootChecker {
+	return &BootChecker{
+		Config:         config,
+		cmdlinePath:    cmdlinePath,
+		osReleasePath:  osReleasePath,
+		secureBootPath: secureBootPath,
+	}
+}
+
+// CheckBoot verifies the kernel command line, Secure Boot state and kernel release. Commands are executed once if any check fails. Returns the reasons of all failed checks.
+func (b *BootChecker) CheckBoot(noExec, debug bool) []string {
+	var reasons []string
+	reasons = append(reasons, b.checkCmdline(debug)...)
+	reasons = append(reasons, b.checkSecureBoot(debug)...)
+	reasons = append(reasons, b.checkKernelRelease(debug)...)
+
+	if len(reasons) > 0 {
+		for _, reason := range reasons {
+			b.Config.printAndLog("Boot integrity check failed: " + reason)
+		}
+		b.Config.execWithEnv(debug, CalleeBoot, b.Config.BootCommandId, noExec, "GOTRACK_BOOT_REASON="+strings.Join(reasons, "; "))
+	} else {
+		b.Config.printAndLog("Boot integrity check passed")
+	}
+	return reasons
+}
+
+// checkCmdline checks the kernel command line against the expected and forbidden patterns
+func (b *BootChecker) checkCmdline(debug bool) []string {
+	if len(b.Config.BootCmdlinePattern) == 0 && len(b.Config.BootCmdlineForbidden) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(b.cmdlinePath)
+	if err != nil {
+		b.Config.logErr(err)
+		return []string{"kernel command line not readable"}
+	}
+	cmdline := strings.TrimSpace(string(data))
+	if debug {
+		b.Config.log("Kernel command line: " + cmdline)
+	}
+
+	var reasons []string
+	if len(b.Config.BootCmdlinePattern) > 0 {
+		pattern, err := regexp.Compile(b.Config.BootCmdlinePattern)
+		if err != nil {
+			b.Config.logErr(err)
+			reasons = append(reasons, "invalid kernel command line pattern")
+		} else if !pattern.MatchString(cmdline) {
+			reasons = append(reasons, "kernel command line not matching: "+cmdline)
+		}
+	}
+	for _, forbidden := range b.Config.BootCmdlineForbidden {
+		pattern, err := regexp.Compile(forbidden)
+		if err != nil {
+			b.Config.logErr(err)
+			reasons = append(reasons, "invalid forbidden kernel command line pattern: "+forbidden)
+		} else if pattern.MatchString(cmdline) {
+			reasons = append(reasons, "forbidden kernel command line pattern found: "+forbidden)
+		}
+	}
+	return reasons
+}
+
+// checkSecureBoot checks the Secure Boot state in efivars if required
+func (b *BootChecker) checkSecureBoot(debug bool) []string {
+	if !b.Config.BootSecureBoot {
+		return nil
+	}
+	// The variable holds 4 bytes of attributes followed by the value
+	data, err := os.ReadFile(b.secureBootPath)
+	if err != nil {
+		if debug {
+			b.Config.logErr(err)
+		}
+		return []string{"Secure Boot state not readable"}
+	}
+	if len(data) < 5 || data[4] != 1 {
+		return []string{"Secure Boot disabled"}
+	}
+	return nil
+}
+
+// checkKernelRelease checks the running kernel release against the allowlist
+func (b *BootChecker) checkKernelRelease(debug bool) []string {
+	if len(b.Config.BootKernelReleases) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(b.osReleasePath)
+	if err != nil {
+		b.Config.logErr(err)
+		return []string{"kernel release not readable"}
+	}
+	release := strings.TrimSpace(string(data))
+	if debug {
+		b.Config.log("Kernel release: " + release)
+	}
+	if !has(b.Config.BootKernelReleases, release) {
+		return []string{"kernel release not allowed: " + release}
+	}
+	return nil
+}
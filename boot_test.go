@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBootChecker_CheckBoot(t *testing.T) {
+	dir := t.TempDir()
+	cmdline := filepath.Join(dir, "cmdline")
+	release := filepath.Join(dir, "osrelease")
+	secureBoot := filepath.Join(dir, "SecureBoot")
+	writeFile := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Error writing %s: %v", path, err)
+		}
+	}
+	writeFile(release, "6.8.0-45-generic\n")
+
+	tests := []struct {
+		name       string
+		cmdline    string
+		secureBoot []byte
+		configure  func(c *Config)
+		want       int
+	}{
+		{
+			name:      "No checks configured",
+			cmdline:   "BOOT_IMAGE=/vmlinuz single",
+			configure: func(c *Config) {},
+			want:      0,
+		},
+		{
+			name:    "Expected boot",
+			cmdline: "BOOT_IMAGE=/vmlinuz root=/dev/mapper/root ro quiet",
+			configure: func(c *Config) {
+				c.BootCmdlinePattern = "root=/dev/mapper/"
+				c.BootCmdlineForbidden = []string{"init=/bin/(ba)?sh", `\bsingle\b`}
+				c.BootSecureBoot = true
+				c.BootKernelReleases = []string{"6.8.0-45-generic"}
+			},
+			secureBoot: []byte{6, 0, 0, 0, 1},
+			want:       0,
+		},
+		{
+			name:    "Forbidden init and single user mode",
+			cmdline: "BOOT_IMAGE=/vmlinuz root=/dev/mapper/root init=/bin/sh single",
+			configure: func(c *Config) {
+				c.BootCmdlineForbidden = []string{"init=/bin/(ba)?sh", `\bsingle\b`}
+			},
+			want: 2,
+		},
+		{
+			name:    "Pattern not matching",
+			cmdline: "BOOT_IMAGE=/vmlinuz root=/dev/sda1",
+			configure: func(c *Config) {
+				c.BootCmdlinePattern = "root=/dev/mapper/"
+			},
+			want: 1,
+		},
+		{
+			name:       "Secure Boot disabled",
+			cmdline:    "",
+			secureBoot: []byte{6, 0, 0, 0, 0},
+			configure: func(c *Config) {
+				c.BootSecureBoot = true
+			},
+			want: 1,
+		},
+		{
+			name:    "Secure Boot missing",
+			cmdline: "",
+			configure: func(c *Config) {
+				c.BootSecureBoot = true
+			},
+			want: 1,
+		},
+		{
+			name:    "Kernel release not allowed",
+			cmdline: "",
+			configure: func(c *Config) {
+				c.BootKernelReleases = []string{"6.8.0-40-generic"}
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(cmdline, tt.cmdline+"\n")
+			_ = os.Remove(secureBoot)
+			if tt.secureBoot != nil {
+				writeFile(secureBoot, string(tt.secureBoot))
+			}
+			config := NewConfig()
+			config.LogFile = ""
+			tt.configure(config)
+			b := NewBootChecker(config)
+			b.cmdlinePath = cmdline
+			b.osReleasePath = release
+			b.secureBootPath = secureBoot
+			if got := b.CheckBoot(true, false); len(got) != tt.want {
+				t.Errorf("CheckBoot() = %v, want %v reasons", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/sys/unix"
+)
+
+const CheckinSourceCommand = "command"
+const CheckinSourceFile = "file"
+const CheckinSourceHTTP = "http"
+
+const CheckinStageWarning = "warning"
+const CheckinStageDeadline = "deadline"
+
+// checkinClockSkew is the time a signed check-in may be ahead of the local clock
+const checkinClockSkew = 5 * time.Minute
+
+// CheckinTracker represents the dead man's switch. Commands are executed if no valid check-in arrives within CheckinDeadline.
+type CheckinTracker struct {
+	Config *Config
+	// web provides the HTTP client for signed check-ins
+	web *WebTracker
+	// last is the time of the last valid check-in
+	last time.Time
+	// warned holds the indices of CheckinWarnings executed since the last check-in
+	warned  map[int]bool
+	expired bool
+	now     func() time.Time
+}
+
+// NewCheckinTracker creates a new CheckinTracker instance
+func NewCheckinTracker(config *Config) *CheckinTracker {
+	return &CheckinTracker{
+		Config: config,
+		web:    NewWebTracker(config),
+		warned: make(map[int]bool),
+		now:    time.Now,
+	}
+}
+
+// InitCheckin reads the last check-in from CheckinStatePath. The deadline starts now if there was no check-in yet.
+func (c *CheckinTracker) InitCheckin(verbose, debug bool) {
+	last, err := readCheckin(c.Config.CheckinStatePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.Config.logErr(err)
+		}
+		c.Config.log("No previous check-in found, deadline starts now")
+		last = c.now()
+		if err := writeCheckin(c.Config.CheckinStatePath, last); err != nil {
+			c.Config.logErr(err)
+		}
+	}
+	c.last = last
+	if verbose {
+		fmt.Println("Last check-in: " + c.last.Format(time.RFC3339) + "\nDeadline: " + c.last.Add(c.Config.CheckinDeadline).Format(time.RFC3339))
+	}
+}
+
+// TrackCheckin looks for new check-ins and executes the warnings and the final commands once the remaining time falls below them.
+// Meant to be executed periodically. Returns the number of executions.
+func (c *CheckinTracker) TrackCheckin(noExec, debug bool) uint {
+	c.update(debug)
+	remaining := c.last.Add(c.Config.CheckinDeadline).Sub(c.now())
+	if debug {
+		c.Config.log("Check-in remaining: " + remaining.String())
+	}
+
+	var counter uint = 0
+	if remaining <= 0 {
+		if !c.expired {
+			c.expired = true
+			counter++
+			c.Config.printAndLog("No check-in since " + c.last.Format(time.RFC3339) + ", deadline exceeded")
+			c.Config.execWithEnv(debug, CalleeCheckin, c.Config.CheckinCommandId, noExec,
+				"GOTRACK_CHECKIN_STAGE="+CheckinStageDeadline,
+				"GOTRACK_CHECKIN_LAST="+c.last.Format(time.RFC3339),
+				"GOTRACK_CHECKIN_REMAINING="+remaining.String(),
+			)
+		}
+		return counter
+	}
+	for i, warning := range c.Config.CheckinWarnings {
+		if remaining > warning.Remaining || c.warned[i] {
+			continue
+		}
+		c.warned[i] = true
+		counter++
+		c.Config.printAndLog("No check-in since " + c.last.Format(time.RFC3339) + ", deadline in " + remaining.Round(time.Second).String())
+		c.Config.execWithEnv(debug, CalleeCheckin, warning.CommandId, noExec,
+			"GOTRACK_CHECKIN_STAGE="+CheckinStageWarning,
+			"GOTRACK_CHECKIN_LAST="+c.last.Format(time.RFC3339),
+			"GOTRACK_CHECKIN_REMAINING="+remaining.String(),
+		)
+	}
+	return counter
+}
+
+// update collects check-ins from all sources. A newer check-in is persisted and resets the warnings.
+func (c *CheckinTracker) update(debug bool) {
+	type checkin struct {
+		source string
+		time   time.Time
+	}
+	var checkins []checkin
+	// goTrack checkin writes the state file, so it is read on every call
+	if last, err := readCheckin(c.Config.CheckinStatePath); err == nil {
+		checkins = append(checkins, checkin{CheckinSourceCommand, last})
+	} else if debug {
+		c.Config.logErr(err)
+	}
+	if len(c.Config.CheckinFilePath) > 0 {
+		if info, err := os.Stat(c.Config.CheckinFilePath); err == nil {
+			checkins = append(checkins, checkin{CheckinSourceFile, info.ModTime()})
+		} else if debug {
+			c.Config.logErr(err)
+		}
+	}
+	if len(c.Config.CheckinURL) > 0 {
+		if signed, err := c.fetchSigned(debug); err == nil {
+			checkins = append(checkins, checkin{CheckinSourceHTTP, signed})
+		} else {
+			c.Config.logErr(err)
+		}
+	}
+
+	for _, received := range checkins {
+		// The state file holds seconds only
+		received.time = received.time.Truncate(time.Second)
+		// Check-ins from the future are not accepted, so a wrong clock can not postpone the deadline
+		if !received.time.After(c.last) || received.time.After(c.now().Add(checkinClockSkew)) {
+			continue
+		}
+		c.last = received.time
+		c.warned = make(map[int]bool)
+		c.expired = false
+		c.Config.printAndLog("Check-in received via " + received.source + " at: " + received.time.Format(time.RFC3339))
+		if received.source != CheckinSourceCommand {
+			if err := writeCheckin(c.Config.CheckinStatePath, c.last); err != nil {
+				c.Config.logErr(err)
+			}
+		}
+	}
+}
+
+// fetchSigned requests CheckinURL and verifies the signature of the response
+func (c *CheckinTracker) fetchSigned(debug bool) (time.Time, error) {
+	wC := c.web.curl(debug, WebTarget{Target: c.Config.CheckinURL})
+	if wC.status != 200 {
+		return time.Time{}, errors.New("no check-in received from " + c.Config.CheckinURL + ", status: " + strconv.Itoa(wC.status))
+	}
+	return verifySignedCheckin(wC.content, c.Config.CheckinSecret)
+}
+
+// verifySignedCheckin verifies a response like "<unix time> <hex HMAC-SHA256 of the unix time>" and returns the time
+func verifySignedCheckin(content, secret string) (time.Time, error) {
+	if len(secret) == 0 {
+		return time.Time{}, errors.New("no check-in secret configured")
+	}
+	fields := strings.Fields(content)
+	if len(fields) != 2 {
+		return time.Time{}, errors.New("invalid signed check-in")
+	}
+	signature, err := hex.DecodeString(fields[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !hmac.Equal(signature, signCheckin(fields[0], secret)) {
+		return time.Time{}, errors.New("invalid signature of check-in")
+	}
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// signCheckin returns the HMAC-SHA256 of the timestamp
+func signCheckin(timestamp, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	return mac.Sum(nil)
+}
+
+// checkinHashCost is the bcrypt cost of hashes created by "goTrack checkin-hash"
+const checkinHashCost = 12
+
+// verifyPassphrase compares the passphrase to the configured bcrypt hash. The comparison runs in constant time.
+func verifyPassphrase(passphrase, hash string) bool {
+	if len(hash) == 0 {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(passphrase)) == nil
+}
+
+// HashPassphrase reads the passphrase from stdin and returns its bcrypt hash for checkin_passphrase_hash. Meant to be
+// executed by "goTrack checkin-hash".
+func HashPassphrase() (string, error) {
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(passphrase), checkinHashCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Checkin reads the passphrase from stdin and records a check-in if it is valid. Meant to be executed by "goTrack checkin".
+func Checkin(config *Config) error {
+	if len(config.CheckinPassphraseHash) == 0 {
+		return errors.New("no check-in passphrase configured")
+	}
+	if _, err := bcrypt.Cost([]byte(config.CheckinPassphraseHash)); err != nil {
+		return errors.New("check-in passphrase hash is no bcrypt hash, create one with \"goTrack checkin-hash\"")
+	}
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return err
+	}
+	if !verifyPassphrase(passphrase, config.CheckinPassphraseHash) {
+		config.log("Invalid check-in passphrase")
+		return errors.New("invalid passphrase")
+	}
+	if err := writeCheckin(config.CheckinStatePath, time.Now()); err != nil {
+		return err
+	}
+	config.log("Check-in recorded")
+	return nil
+}
+
+// readPassphrase reads a line from stdin. The input is not echoed if stdin is a terminal.
+func readPassphrase() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if termios, err := unix.IoctlGetTermios(fd, unix.TCGETS); err == nil {
+		fmt.Print("Passphrase: ")
+		silent := *termios
+		silent.Lflag &^= unix.ECHO
+		if err := unix.IoctlSetTermios(fd, unix.TCSETS, &silent); err != nil {
+			return "", err
+		}
+		defer func() {
+			_ = unix.IoctlSetTermios(fd, unix.TCSETS, termios)
+			fmt.Println()
+		}()
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && len(line) == 0 {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readCheckin reads the time of the last check-in from the state file
+func readCheckin(path string) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+}
+
+// writeCheckin writes the time of the check-in to the state file
+func writeCheckin(path string, last time.Time) error {
+	createPath(path)
+	return os.WriteFile(path, []byte(last.Format(time.RFC3339)+"\n"), 0600)
+}
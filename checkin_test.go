@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCheckinTracker_TrackCheckin(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	signed := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(signed))
+	}))
+	defer server.Close()
+	sign := func(at time.Time) string {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		return timestamp + " " + hex.EncodeToString(signCheckin(timestamp, "secret"))
+	}
+
+	config := &Config{
+		CheckinDeadline:  24 * time.Hour,
+		CheckinStatePath: filepath.Join(dir, "state", "checkin"),
+		CheckinFilePath:  filepath.Join(dir, "touch"),
+		CheckinURL:       server.URL,
+		CheckinSecret:    "secret",
+		CheckinWarnings:  []CheckinWarning{{Remaining: 2 * time.Hour, CommandId: 1}, {Remaining: 15 * time.Minute, CommandId: 1}},
+		CheckinCommandId: 2,
+	}
+	c := NewCheckinTracker(config)
+	c.now = func() time.Time { return now }
+	c.InitCheckin(false, false)
+	if last, err := readCheckin(config.CheckinStatePath); err != nil || !last.Equal(start) {
+		t.Fatalf("InitCheckin() state = %v, %v, want %v", last, err, start)
+	}
+
+	steps := []struct {
+		name   string
+		after  time.Duration
+		action func()
+		want   uint
+	}{
+		{name: "within deadline", after: time.Hour, want: 0},
+		{name: "first warning", after: 22 * time.Hour, want: 1},
+		{name: "first warning only once", after: 23 * time.Hour, want: 0},
+		{name: "both warnings passed", after: 23*time.Hour + 50*time.Minute, want: 1},
+		{name: "deadline", after: 24 * time.Hour, want: 1},
+		{name: "deadline only once", after: 25 * time.Hour, want: 0},
+		{
+			name:  "touched file resets deadline",
+			after: 26 * time.Hour,
+			action: func() {
+				if err := os.WriteFile(config.CheckinFilePath, nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(config.CheckinFilePath, start.Add(26*time.Hour), start.Add(26*time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: 0,
+		},
+		{name: "warning after touched file", after: 48 * time.Hour, want: 1},
+		{
+			name:   "invalid signature ignored",
+			after:  48*time.Hour + 30*time.Minute,
+			action: func() { signed = strconv.FormatInt(start.Add(48*time.Hour).Unix(), 10) + " 00" },
+			want:   0,
+		},
+		{
+			name:   "signed check-in from the future ignored",
+			after:  49 * time.Hour,
+			action: func() { signed = sign(start.Add(100 * time.Hour)) },
+			want:   0,
+		},
+		{
+			name:   "signed check-in resets deadline",
+			after:  49*time.Hour + 55*time.Minute,
+			action: func() { signed = sign(start.Add(49 * time.Hour)) },
+			want:   0,
+		},
+		{
+			name:   "goTrack checkin resets deadline",
+			after:  72 * time.Hour,
+			action: func() { _ = writeCheckin(config.CheckinStatePath, start.Add(71*time.Hour)) },
+			want:   0,
+		},
+		{name: "deadline after goTrack checkin", after: 95 * time.Hour, want: 1},
+	}
+	for _, step := range steps {
+		now = start.Add(step.after)
+		if step.action != nil {
+			step.action()
+		}
+		if got := c.TrackCheckin(true, false); got != step.want {
+			t.Errorf("%s: TrackCheckin() = %v, want %v", step.name, got, step.want)
+		}
+	}
+	if last, err := readCheckin(config.CheckinStatePath); err != nil || !last.Equal(start.Add(71*time.Hour)) {
+		t.Errorf("state = %v, %v, want %v", last, err, start.Add(71*time.Hour))
+	}
+}
+
+func Test_verifySignedCheckin(t *testing.T) {
+	signature := hex.EncodeToString(signCheckin("1700000000", "secret"))
+	tests := []struct {
+		name    string
+		content string
+		secret  string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "valid", content: "1700000000 " + signature + "\n", secret: "secret", want: time.Unix(1700000000, 0)},
+		{name: "other secret", content: "1700000000 " + signature, secret: "other", wantErr: true},
+		{name: "other time", content: "1700000001 " + signature, secret: "secret", wantErr: true},
+		{name: "no secret", content: "1700000000 " + signature, wantErr: true},
+		{name: "no signature", content: "1700000000", secret: "secret", wantErr: true},
+		{name: "invalid hex", content: "1700000000 xyz", secret: "secret", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifySignedCheckin(tt.content, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifySignedCheckin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("verifySignedCheckin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_verifyPassphrase(t *testing.T) {
+	generated, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := string(generated)
+	sum := sha256.Sum256([]byte("correct horse"))
+	tests := []struct {
+		name       string
+		passphrase string
+		hash       string
+		want       bool
+	}{
+		{name: "valid", passphrase: "correct horse", hash: hash, want: true},
+		{name: "htpasswd prefix", passphrase: "correct horse", hash: strings.Replace(hash, "$2a$", "$2y$", 1), want: true},
+		{name: "invalid", passphrase: "wrong", hash: hash, want: false},
+		{name: "unsalted sha256", passphrase: "correct horse", hash: hex.EncodeToString(sum[:]), want: false},
+		{name: "no hash", passphrase: "", hash: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPassphrase(tt.passphrase, tt.hash); got != tt.want {
+				t.Errorf("verifyPassphrase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckin_invalidHash(t *testing.T) {
+	sum := sha256.Sum256([]byte("correct horse"))
+	if err := Checkin(&Config{CheckinPassphraseHash: hex.EncodeToString(sum[:])}); err == nil || !strings.Contains(err.Error(), "bcrypt") {
+		t.Errorf("Checkin() error = %v, want bcrypt error", err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const suspendStatsPath = "/sys/power/suspend_stats/success"
+
+const ClockSuspend = "suspend"
+const ClockHibernate = "hibernate"
+const ClockJump = "clock_jump"
+
+// clockReading represents the state of all clocks at one point in time
+type clockReading struct {
+	// monotonic does not advance while the system is suspended
+	monotonic time.Duration
+	// boottime includes the time the system was suspended
+	boottime time.Duration
+	wall     time.Time
+	// suspends is the number of successful suspends, -1 if unknown
+	suspends int
+}
+
+// ClockTracker represents the suspend/resume and clock jump tracking service
+type ClockTracker struct {
+	Config *Config
+	last   *clockReading
+	// read returns the current clockReading
+	read func() (clockReading, error)
+}
+
+// NewClockTracker creates a new ClockTracker instance
+func NewClockTracker(config *Config) *ClockTracker {
+	return &ClockTracker{
+		Config: config,
+		read:   readClocks,
+	}
+}
+
+// TrackClocks compares the clock deltas since the last call. Meant to be executed periodically. Returns the detected events.
+func (c *ClockTracker) TrackClocks(noExec, debug bool) []string {
+	current, err := c.read()
+	if err != nil {
+		c.Config.logErr(err)
+		if c.Config.ExecOnError {
+			c.Config.exec(debug, CalleeClock, -1, noExec)
+		}
+		return nil
+	}
+	last := c.last
+	c.last = &current
+	if last == nil {
+		return nil
+	}
+
+	var events []string
+	deltaMonotonic := current.monotonic - last.monotonic
+	deltaBoottime := current.boottime - last.boottime
+	deltaWall := current.wall.Sub(last.wall)
+	if debug {
+		c.Config.log("Clock deltas: monotonic " + deltaMonotonic.String() + " boottime " + deltaBoottime.String() + " wall " + deltaWall.String())
+	}
+
+	// The system was asleep if the boottime advanced further than the monotonic clock
+	if slept := deltaBoottime - deltaMonotonic; slept > c.Config.ClockTolerance {
+		// Suspend to RAM is counted by the kernel, hibernation is not
+		if current.suspends < 0 || current.suspends > last.suspends {
+			events = append(events, ClockSuspend)
+			c.Config.log("Resume from suspend detected after: " + slept.String())
+			if c.Config.ClockOnSuspend {
+				c.Config.execWithEnv(debug, CalleeClock, c.Config.ClockSuspendCommandId, noExec, "GOTRACK_CLOCK_EVENT="+ClockSuspend, "GOTRACK_CLOCK_DURATION="+slept.String())
+			}
+		} else {
+			events = append(events, ClockHibernate)
+			c.Config.log("Resume from hibernation detected after: " + slept.String())
+			if c.Config.ClockOnHibernate {
+				c.Config.execWithEnv(debug, CalleeClock, c.Config.ClockHibernateCommandId, noExec, "GOTRACK_CLOCK_EVENT="+ClockHibernate, "GOTRACK_CLOCK_DURATION="+slept.String())
+			}
+		}
+	}
+
+	// The wall clock was changed if it differs from the boottime which is not adjustable
+	if jump := deltaWall - deltaBoottime; jump > c.Config.ClockTolerance || jump < -c.Config.ClockTolerance {
+		events = append(events, ClockJump)
+		c.Config.log("Wall clock changed by: " + jump.String())
+		if c.Config.ClockOnJump {
+			c.Config.execWithEnv(debug, CalleeClock, c.Config.ClockJumpCommandId, noExec, "GOTRACK_CLOCK_EVENT="+ClockJump, "GOTRACK_CLOCK_DURATION="+jump.String())
+		}
+	}
+	return events
+}
+
+// readClocks reads CLOCK_MONOTONIC, CLOCK_BOOTTIME, the wall clock and the suspend counter
+func readClocks() (clockReading, error) {
+	var monotonic, boottime unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &monotonic); err != nil {
+		return clockReading{}, err
+	}
+	if err := unix.ClockGettime(unix.CLOCK_BOOTTIME, &boottime); err != nil {
+		return clockReading{}, err
+	}
+	reading := clockReading{
+		monotonic: time.Duration(monotonic.Nano()),
+		boottime:  time.Duration(boottime.Nano()),
+		// Round(0) strips the monotonic reading, so only the wall clock is compared
+		wall:     time.Now().Round(0),
+		suspends: -1,
+	}
+	if data, err := os.ReadFile(suspendStatsPath); err == nil {
+		if suspends, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			reading.suspends = suspends
+		}
+	}
+	return reading, nil
+}
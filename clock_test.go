@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClockTracker_TrackClocks(t *testing.T) {
+	start := clockReading{
+		monotonic: 100 * time.Second,
+		boottime:  110 * time.Second,
+		wall:      time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		suspends:  3,
+	}
+	tests := []struct {
+		name string
+		next clockReading
+		want []string
+	}{
+		{
+			name: "Regular tick",
+			next: clockReading{monotonic: 101 * time.Second, boottime: 111 * time.Second, wall: start.wall.Add(time.Second), suspends: 3},
+			want: nil,
+		},
+		{
+			name: "Suspend",
+			next: clockReading{monotonic: 101 * time.Second, boottime: 711 * time.Second, wall: start.wall.Add(601 * time.Second), suspends: 4},
+			want: []string{ClockSuspend},
+		},
+		{
+			name: "Hibernate",
+			next: clockReading{monotonic: 101 * time.Second, boottime: 711 * time.Second, wall: start.wall.Add(601 * time.Second), suspends: 3},
+			want: []string{ClockHibernate},
+		},
+		{
+			name: "Suspend without counter",
+			next: clockReading{monotonic: 101 * time.Second, boottime: 711 * time.Second, wall: start.wall.Add(601 * time.Second), suspends: -1},
+			want: []string{ClockSuspend},
+		},
+		{
+			name: "Wall clock set back",
+			next: clockReading{monotonic: 101 * time.Second, boottime: 111 * time.Second, wall: start.wall.Add(-time.Hour), suspends: 3},
+			want: []string{ClockJump},
+		},
+		{
+			name: "Wall clock set forward during suspend",
+			next: clockReading{monotonic: 101 * time.Second, boottime: 711 * time.Second, wall: start.wall.Add(24 * time.Hour), suspends: 4},
+			want: []string{ClockSuspend, ClockJump},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewConfig()
+			config.LogFile = ""
+			c := NewClockTracker(config)
+			readings := []clockReading{start, tt.next}
+			c.read = func() (clockReading, error) {
+				reading := readings[0]
+				readings = readings[1:]
+				return reading, nil
+			}
+			if got := c.TrackClocks(true, false); got != nil {
+				t.Errorf("TrackClocks() on first call = %v, want nil", got)
+			}
+			if got := c.TrackClocks(true, false); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TrackClocks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readClocks(t *testing.T) {
+	first, err := readClocks()
+	if err != nil {
+		t.Fatalf("readClocks() error = %v", err)
+	}
+	second, err := readClocks()
+	if err != nil {
+		t.Fatalf("readClocks() error = %v", err)
+	}
+	if second.monotonic < first.monotonic || second.boottime < first.boottime {
+		t.Errorf("readClocks() not monotonic: %v, %v", first, second)
+	}
+	if first.boottime < first.monotonic {
+		t.Errorf("readClocks() boottime %v smaller than monotonic %v", first.boottime, first.monotonic)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -17,11 +18,151 @@ const CalleePing uint8 = 2
 const CalleeWeb uint8 = 3
 const CalleeTime uint8 = 4
 const CalleeInterval uint8 = 5
+const CalleeLogin uint8 = 6
+const CalleeAuth uint8 = 7
+const CalleeLog uint8 = 8
+const CalleeKmsg uint8 = 9
+const CalleeModule uint8 = 10
+const CalleeSysctl uint8 = 11
+const CalleeBoot uint8 = 12
+const CalleeReboot uint8 = 13
+const CalleeClock uint8 = 14
+const CalleeDisplay uint8 = 15
+const CalleeBluetooth uint8 = 16
+const CalleeDev uint8 = 17
+const CalleeAccount uint8 = 18
+const CalleeUnit uint8 = 19
+const CalleeFirewall uint8 = 20
+const CalleeDNS uint8 = 21
+const CalleePublicIP uint8 = 22
+const CalleeCheckin uint8 = 23
 const ExecSuc uint8 = 0
 const ExecErr uint8 = 1
 const NoExec uint8 = 2
 const FileLock uint8 = 3
 
+// LoginTarget represents the configuration struct for login sessions to be tracked.
+type LoginTarget struct {
+	// Users restricts this rule to logins of the listed users. Ignored if empty.
+	Users []string `yaml:"users"`
+	// AllowedUsers restricts this rule to logins of users not listed. Ignored if empty.
+	AllowedUsers []string `yaml:"allowed_users"`
+	// TTYs restricts this rule to terminals matching one of the glob patterns like "pts/*" or "tty[1-6]". Ignored if empty.
+	TTYs []string `yaml:"ttys"`
+	// Hosts restricts this rule to remote hosts matching one of the glob patterns like "192.168.1.*". Ignored if empty.
+	Hosts []string `yaml:"hosts"`
+	// If RemoteOnly is true only sessions with a remote host (like SSH) are matched.
+	RemoteOnly bool `yaml:"remote_only"`
+	// If LocalOnly is true only sessions without a remote host (like local TTYs) are matched.
+	LocalOnly bool `yaml:"local_only"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// AuthTarget represents the configuration struct for authentication failures to be tracked.
+type AuthTarget struct {
+	// Services restricts this rule to failures found by the listed parsers: "sshd", "sudo", "su" and "login". All are used if empty.
+	Services []string `yaml:"services"`
+	// Threshold is the number of failures within Window to execute the configured commands.
+	Threshold int `yaml:"threshold"`
+	// Window is the duration failures are counted for. If 0, failures are counted until the threshold is reached.
+	Window time.Duration `yaml:"window"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// LogTarget represents the configuration struct for log files to be tracked.
+type LogTarget struct {
+	// Path of the file to follow. Rotation and truncation are handled like "tail -F".
+	Path string `yaml:"path"`
+	// Pattern is the regular expression lines are matched with.
+	Pattern string `yaml:"pattern"`
+	// Count is the number of matching lines within Window to execute the configured commands. Values below 2 execute on every match.
+	Count int `yaml:"count"`
+	// Window is the duration matches are counted for. If 0, matches are counted until Count is reached.
+	Window time.Duration `yaml:"window"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// KmsgTarget represents the configuration struct for kernel messages to be tracked.
+type KmsgTarget struct {
+	// Facility restricts this rule to a syslog facility like "kern" or "user". Ignored if empty.
+	Facility string `yaml:"facility"`
+	// Priority restricts this rule to messages of this priority like "err" or more severe. Ignored if empty.
+	Priority string `yaml:"priority"`
+	// Pattern restricts this rule to messages matching the regular expression. Ignored if empty.
+	Pattern string `yaml:"pattern"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// SysctlTarget represents the configuration struct for sysctl keys to be tracked.
+type SysctlTarget struct {
+	// Key like "kernel.kptr_restrict"
+	Key string `yaml:"key"`
+	// Expected is the exact value of the key. Ignored if empty.
+	Expected string `yaml:"expected"`
+	// Min is the minimal numeric value of the key. Ignored if not set.
+	Min *int64 `yaml:"min"`
+	// Max is the maximal numeric value of the key. Ignored if not set.
+	Max *int64 `yaml:"max"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// BluetoothTarget represents the configuration struct for Bluetooth events to be tracked.
+type BluetoothTarget struct {
+	// If OnAdapterAdded is true the configured commands will be executed if a new adapter appears.
+	OnAdapterAdded bool `yaml:"on_adapter_added"`
+	// If OnPowered is true the configured commands will be executed if an adapter becomes powered.
+	OnPowered bool `yaml:"on_powered"`
+	// If OnPaired is true the configured commands will be executed if a device not in AllowedDevices is paired.
+	OnPaired bool `yaml:"on_paired"`
+	// If OnConnected is true the configured commands will be executed if a device not in AllowedDevices connects.
+	OnConnected bool `yaml:"on_connected"`
+	// AllowedDevices holds addresses like "AA:BB:CC:DD:EE:FF" of devices that shall not trigger.
+	AllowedDevices []string `yaml:"allowed_devices"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// DevTarget represents the configuration struct for device nodes to be tracked.
+type DevTarget struct {
+	// Patterns holds globs like "/dev/ttyUSB*" matched against the path of the node
+	Patterns []string `yaml:"patterns"`
+	// If OnCreate is true the configured commands will be executed if a matching node is created.
+	OnCreate bool `yaml:"on_create"`
+	// If OnRemove is true the configured commands will be executed if a matching node is removed.
+	OnRemove bool `yaml:"on_remove"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// AccountTarget represents the configuration struct for account events to be tracked.
+type AccountTarget struct {
+	// Events holds the events like "uid0_account" to react to, all events if empty
+	Events []string `yaml:"events"`
+	// Users holds globs matched against the affected user, all users if empty
+	Users []string `yaml:"users"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// UnitTarget represents the configuration struct for systemd units to be tracked.
+type UnitTarget struct {
+	// Unit is the name of the unit like "nftables.service"
+	Unit string `yaml:"unit"`
+	// States holds the active states considered as down, "inactive" and "failed" if empty
+	States []string `yaml:"states"`
+	// RetryCount defines the number of retries before command execution.
+	RetryCount int `yaml:"retry_count"`
+	// RetryDelay defines the time in milliseconds to wait between two queries.
+	RetryDelay time.Duration `yaml:"retry_delay"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
 // IntervalTarget represents the configuration struct for timestamps to be tracked.
 type IntervalTarget struct {
 	Interval time.Duration `yaml:"interval"`
@@ -61,6 +202,59 @@ type WebTarget struct {
 	RetryCount int `yaml:"retry_count"`
 	// If OnHTTPSFails is false RetryDelay defines the time in milliseconds to wait between two tries to curl.
 	RetryDelay time.Duration `yaml:"retry_delay"`
+	// Interval defines the time between two checks of this target, WebInterval if not set.
+	Interval time.Duration `yaml:"interval"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// DNSTarget represents the configuration struct for DNS names to be tracked.
+type DNSTarget struct {
+	Name string `yaml:"name"`
+	// Type defines the record type "A", "AAAA" or "TXT", A if not set.
+	Type string `yaml:"type"`
+	// Resolver defines the DNS server like "9.9.9.9:53" to query. The system resolver is used if not set.
+	Resolver string `yaml:"resolver"`
+	// Timeout defines the time to wait for a response, 5s if not set.
+	Timeout time.Duration `yaml:"timeout"`
+	// Expected will be ignored if empty. Otherwise the configured commands will be executed if any answer is not in Expected or there is no answer.
+	Expected []string `yaml:"expected"`
+	// Content and ContentIsExact will be ignored if this is the empty string.
+	Content string `yaml:"content"`
+	// If ContentIsExact is true the configured commands will be executed if an answer is exactly Content. If false they will be executed if Content is a substring of an answer.
+	ContentIsExact bool `yaml:"content_is_exact"`
+	// If OnFailure is true the configured commands will be executed if the lookup fails after all retries.
+	OnFailure bool `yaml:"on_failure"`
+	// RetryCount defines the number of retries if the lookup fails.
+	RetryCount int `yaml:"retry_count"`
+	// RetryDelay defines the time in milliseconds to wait between two lookups.
+	RetryDelay time.Duration `yaml:"retry_delay"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// PublicIPTarget represents the configuration struct for sources of the public IP to be tracked.
+type PublicIPTarget struct {
+	// Target defines a "what is my IP" HTTP endpoint returning the address as plain text or a STUN server like "stun:stun.example.com:3478".
+	Target string `yaml:"target"`
+	// AllowedCIDRs will be ignored if empty. Otherwise the configured commands will be executed if the public IP is in none of the ranges.
+	AllowedCIDRs []string `yaml:"allowed_cidrs"`
+	// If OnChange is true the configured commands will be executed if the public IP differs from the previously seen one.
+	OnChange bool `yaml:"on_change"`
+	// Timeout defines the time to wait for a STUN response, 5s if not set.
+	Timeout time.Duration `yaml:"timeout"`
+	// RetryCount defines the number of retries if the lookup fails.
+	RetryCount int `yaml:"retry_count"`
+	// RetryDelay defines the time in milliseconds to wait between two lookups.
+	RetryDelay time.Duration `yaml:"retry_delay"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// CheckinWarning represents a warning stage of the dead man's switch.
+type CheckinWarning struct {
+	// Remaining defines the time left until CheckinDeadline at which the warning is executed.
+	Remaining time.Duration `yaml:"remaining"`
 	// If CommandId is set, any commands locked for this id will ignore other commands
 	CommandId int `yaml:"command_id"`
 }
@@ -75,6 +269,62 @@ type PingTarget struct {
 	RetryCount int `yaml:"retry_count"`
 	// If OnSuccess is false RetryDelay defines the time in milliseconds to wait between two tries to ping.
 	RetryDelay time.Duration `yaml:"retry_delay"`
+	// Count defines the number of packets sent per try, 1 if not set.
+	Count int `yaml:"count"`
+	// PacketInterval defines the time between two packets of a try, 1s if not set.
+	PacketInterval time.Duration `yaml:"packet_interval"`
+	// MaxLoss defines the maximum packet loss in percent for a successful ping. If not set any received packet is a success.
+	MaxLoss *float64 `yaml:"max_loss"`
+	// MaxAvgRtt defines the maximum average round-trip time for a successful ping. Ignored if zero.
+	MaxAvgRtt time.Duration `yaml:"max_avg_rtt"`
+	// MaxRtt defines the maximum round-trip time of any packet for a successful ping. Ignored if zero.
+	MaxRtt time.Duration `yaml:"max_rtt"`
+	// MaxJitter defines the maximum mean difference between consecutive round-trip times for a successful ping. Ignored if zero.
+	MaxJitter time.Duration `yaml:"max_jitter"`
+	// Mode defines if commands are executed on every check ("level") or only on changes of the up/down state ("transition"). Level if not set.
+	// In transition mode commands are executed when the target goes down, or comes up if OnSuccess is true.
+	Mode string `yaml:"mode"`
+	// FailThreshold defines the number of consecutive failed checks to go down in transition mode, 1 if not set.
+	FailThreshold int `yaml:"fail_threshold"`
+	// RecoverThreshold defines the number of consecutive successful checks to come up in transition mode, 1 if not set.
+	RecoverThreshold int `yaml:"recover_threshold"`
+	// If GroupOnly is true the target is only evaluated by ping groups and does not execute commands itself.
+	GroupOnly bool `yaml:"group_only"`
+	// Family defines the address family "ip4" or "ip6" used to resolve the target. Any family if not set.
+	Family string `yaml:"family"`
+	// Source defines the source address of the packets. Chosen by the system if not set.
+	Source string `yaml:"source"`
+	// Interface defines the network interface like "eth0" the packets are sent through. Chosen by the system if not set.
+	Interface string `yaml:"interface"`
+	// TTL defines the time to live of the packets, 64 if not set.
+	TTL int `yaml:"ttl"`
+	// Size defines the size of the packets in bytes, 24 if not set. Must be at least 24.
+	Size int `yaml:"size"`
+	// If Privileged is true raw ICMP sockets are used, which requires root or CAP_NET_RAW. Otherwise unprivileged UDP sockets are used.
+	Privileged bool `yaml:"privileged"`
+	// Type defines the probe "icmp", "tcp" or "udp", icmp if not set. Family, Source, Interface and TTL apply to all types.
+	Type string `yaml:"type"`
+	// Port defines the port of tcp and udp probes.
+	Port int `yaml:"port"`
+	// Payload defines the content sent by udp probes.
+	Payload string `yaml:"payload"`
+	// If ExpectResponse is true udp probes only succeed if a response is received. Otherwise they succeed if the port is not reported as unreachable.
+	ExpectResponse bool `yaml:"expect_response"`
+	// Interval defines the time between two checks of this target, PingInterval if not set.
+	Interval time.Duration `yaml:"interval"`
+	// If CommandId is set, any commands locked for this id will ignore other commands
+	CommandId int `yaml:"command_id"`
+}
+
+// PingGroup represents the configuration struct for a quorum rule over multiple ping targets.
+type PingGroup struct {
+	Name string `yaml:"name"`
+	// Targets holds the keys of ping targets belonging to the group, the address for ICMP and tcp://host:port or udp://host:port otherwise
+	Targets []string `yaml:"targets"`
+	// Quorum defines the number of responding targets, 1 if not set.
+	Quorum int `yaml:"quorum"`
+	// If OnSuccess is true the configured commands will be executed if at least Quorum targets respond. If false they will be executed if fewer respond.
+	OnSuccess bool `yaml:"on_success"`
 	// If CommandId is set, any commands locked for this id will ignore other commands
 	CommandId int `yaml:"command_id"`
 }
@@ -94,36 +344,166 @@ type Command struct {
 	Time bool `yaml:"time"`
 	// Is this command executed on Interval activation?
 	Interval bool `yaml:"interval"`
+	// Is this command executed on Login activation?
+	Login bool `yaml:"login"`
+	// Is this command executed on Auth activation?
+	Auth bool `yaml:"auth"`
+	// Is this command executed on Log activation?
+	Log bool `yaml:"log"`
+	// Is this command executed on Kmsg activation?
+	Kmsg bool `yaml:"kmsg"`
+	// Is this command executed on Module activation?
+	Module bool `yaml:"module"`
+	// Is this command executed on Sysctl activation?
+	Sysctl bool `yaml:"sysctl"`
+	// Is this command executed on failed boot integrity check?
+	Boot bool `yaml:"boot"`
+	// Is this command executed on unexpected reboot detection?
+	Reboot bool `yaml:"reboot"`
+	// Is this command executed on Clock activation?
+	Clock bool `yaml:"clock"`
+	// Is this command executed on Display activation?
+	Display bool `yaml:"display"`
+	// Is this command executed on Bluetooth activation?
+	Bluetooth bool `yaml:"bluetooth"`
+	// Is this command executed on Dev activation?
+	Dev bool `yaml:"dev"`
+	// Is this command executed on Account activation?
+	Account bool `yaml:"account"`
+	// Is this command executed on Unit activation?
+	Unit bool `yaml:"unit"`
+	// Is this command executed on Firewall activation?
+	Firewall bool `yaml:"firewall"`
+	// Is this command executed on DNS activation?
+	DNS bool `yaml:"dns"`
+	// Is this command executed on public IP activation?
+	PublicIP bool `yaml:"public_ip"`
+	// Is this command executed on check-in activation?
+	Checkin bool `yaml:"checkin"`
 	// Only execute on triggering targets with this id
 	Id int `yaml:"command_id"`
 }
 
 // Config represents the configuration for goTrack
 type Config struct {
-	Version                 string           `yaml:"config_version"`
-	FileLock                bool             `yaml:"file_lock"`
-	FileLockInverted        bool             `yaml:"file_lock_inverted_mode"`
-	FileLockPath            string           `yaml:"file_lock_path"`
-	FileLockDeletion        bool             `yaml:"file_lock_deletion"`
-	FileLockCreation        bool             `yaml:"file_lock_creation"`
-	StartDelay              time.Duration    `yaml:"start_delay"`
-	LogFile                 string           `yaml:"log_file"`
-	OldLogs                 int              `yaml:"old_logs"`
-	ExecOnError             bool             `yaml:"execution_on_error"`
-	USBTracking             bool             `yaml:"usb_tracking"`
-	USBInterval             time.Duration    `yaml:"usb_interval"`
-	IgnoredIDs              []string         `yaml:"usb_ignored_ids"`
-	PingTracking            bool             `yaml:"ping_tracking"`
-	PingInterval            time.Duration    `yaml:"ping_interval"`
-	PingTrackingConfigs     []PingTarget     `yaml:"ping_targets"`
-	WebTracking             bool             `yaml:"web_tracking"`
-	WebInterval             time.Duration    `yaml:"web_interval"`
-	WebTrackingConfigs      []WebTarget      `yaml:"web_targets"`
-	TimeTracking            bool             `yaml:"time_tracking"`
-	TimeTrackingConfigs     []TimeTarget     `yaml:"time_targets"`
-	IntervalTracking        bool             `yaml:"interval_tracking"`
-	IntervalTrackingConfigs []IntervalTarget `yaml:"interval_targets"`
-	Commands                []Command        `yaml:"commands"`
+	Version                  string            `yaml:"config_version"`
+	FileLock                 bool              `yaml:"file_lock"`
+	FileLockInverted         bool              `yaml:"file_lock_inverted_mode"`
+	FileLockPath             string            `yaml:"file_lock_path"`
+	FileLockDeletion         bool              `yaml:"file_lock_deletion"`
+	FileLockCreation         bool              `yaml:"file_lock_creation"`
+	StartDelay               time.Duration     `yaml:"start_delay"`
+	LogFile                  string            `yaml:"log_file"`
+	OldLogs                  int               `yaml:"old_logs"`
+	ExecOnError              bool              `yaml:"execution_on_error"`
+	USBTracking              bool              `yaml:"usb_tracking"`
+	USBInterval              time.Duration     `yaml:"usb_interval"`
+	IgnoredIDs               []string          `yaml:"usb_ignored_ids"`
+	PingTracking             bool              `yaml:"ping_tracking"`
+	PingInterval             time.Duration     `yaml:"ping_interval"`
+	PingTrackingConfigs      []PingTarget      `yaml:"ping_targets"`
+	PingGroups               []PingGroup       `yaml:"ping_groups"`
+	PingHistorySize          int               `yaml:"ping_history_size"`
+	PingExportPath           string            `yaml:"ping_export_path"`
+	PingExportFormat         string            `yaml:"ping_export_format"`
+	WebTracking              bool              `yaml:"web_tracking"`
+	WebInterval              time.Duration     `yaml:"web_interval"`
+	WebTrackingConfigs       []WebTarget       `yaml:"web_targets"`
+	TimeTracking             bool              `yaml:"time_tracking"`
+	TimeTrackingConfigs      []TimeTarget      `yaml:"time_targets"`
+	IntervalTracking         bool              `yaml:"interval_tracking"`
+	IntervalTrackingConfigs  []IntervalTarget  `yaml:"interval_targets"`
+	LoginTracking            bool              `yaml:"login_tracking"`
+	LoginInterval            time.Duration     `yaml:"login_interval"`
+	LoginUtmpPath            string            `yaml:"login_utmp_path"`
+	LoginWtmpPath            string            `yaml:"login_wtmp_path"`
+	LoginTrackingConfigs     []LoginTarget     `yaml:"login_targets"`
+	AuthTracking             bool              `yaml:"auth_tracking"`
+	AuthInterval             time.Duration     `yaml:"auth_interval"`
+	AuthLogPaths             []string          `yaml:"auth_log_paths"`
+	AuthTrackingConfigs      []AuthTarget      `yaml:"auth_targets"`
+	LogTracking              bool              `yaml:"log_tracking"`
+	LogInterval              time.Duration     `yaml:"log_interval"`
+	LogTrackingConfigs       []LogTarget       `yaml:"log_targets"`
+	KmsgTracking             bool              `yaml:"kmsg_tracking"`
+	KmsgPath                 string            `yaml:"kmsg_path"`
+	KmsgTrackingConfigs      []KmsgTarget      `yaml:"kmsg_targets"`
+	ModuleTracking           bool              `yaml:"module_tracking"`
+	ModuleInterval           time.Duration     `yaml:"module_interval"`
+	ModulePath               string            `yaml:"module_path"`
+	ModuleBaseline           bool              `yaml:"module_baseline"`
+	ModuleAllowed            []string          `yaml:"module_allowed"`
+	ModuleRequired           []string          `yaml:"module_required"`
+	ModuleDisableLoading     bool              `yaml:"module_disable_loading"`
+	ModuleCommandId          int               `yaml:"module_command_id"`
+	SysctlTracking           bool              `yaml:"sysctl_tracking"`
+	SysctlInterval           time.Duration     `yaml:"sysctl_interval"`
+	SysctlTrackingConfigs    []SysctlTarget    `yaml:"sysctl_targets"`
+	BootCheck                bool              `yaml:"boot_check"`
+	BootCmdlinePattern       string            `yaml:"boot_cmdline_pattern"`
+	BootCmdlineForbidden     []string          `yaml:"boot_cmdline_forbidden"`
+	BootSecureBoot           bool              `yaml:"boot_secure_boot"`
+	BootKernelReleases       []string          `yaml:"boot_kernel_releases"`
+	BootCommandId            int               `yaml:"boot_command_id"`
+	RebootTracking           bool              `yaml:"reboot_tracking"`
+	RebootStatePath          string            `yaml:"reboot_state_path"`
+	RebootOnUncleanExit      bool              `yaml:"reboot_on_unclean_exit"`
+	RebootBootTimeTolerance  time.Duration     `yaml:"reboot_boot_time_tolerance"`
+	RebootCommandId          int               `yaml:"reboot_command_id"`
+	ClockTracking            bool              `yaml:"clock_tracking"`
+	ClockInterval            time.Duration     `yaml:"clock_interval"`
+	ClockTolerance           time.Duration     `yaml:"clock_tolerance"`
+	ClockOnSuspend           bool              `yaml:"clock_on_suspend"`
+	ClockSuspendCommandId    int               `yaml:"clock_suspend_command_id"`
+	ClockOnHibernate         bool              `yaml:"clock_on_hibernate"`
+	ClockHibernateCommandId  int               `yaml:"clock_hibernate_command_id"`
+	ClockOnJump              bool              `yaml:"clock_on_jump"`
+	ClockJumpCommandId       int               `yaml:"clock_jump_command_id"`
+	DisplayTracking          bool              `yaml:"display_tracking"`
+	DisplayInterval          time.Duration     `yaml:"display_interval"`
+	DisplayAllowedEDIDs      []string          `yaml:"display_allowed_edids"`
+	DisplayCommandId         int               `yaml:"display_command_id"`
+	BluetoothTracking        bool              `yaml:"bluetooth_tracking"`
+	BluetoothInterval        time.Duration     `yaml:"bluetooth_interval"`
+	BluetoothTrackingConfigs []BluetoothTarget `yaml:"bluetooth_targets"`
+	DevTracking              bool              `yaml:"dev_tracking"`
+	DevPath                  string            `yaml:"dev_path"`
+	DevTrackingConfigs       []DevTarget       `yaml:"dev_targets"`
+	AccountTracking          bool              `yaml:"account_tracking"`
+	AccountInterval          time.Duration     `yaml:"account_interval"`
+	AccountPasswdPath        string            `yaml:"account_passwd_path"`
+	AccountShadowPath        string            `yaml:"account_shadow_path"`
+	AccountGroupPath         string            `yaml:"account_group_path"`
+	AccountSudoersPaths      []string          `yaml:"account_sudoers_paths"`
+	AccountPrivilegedGroups  []string          `yaml:"account_privileged_groups"`
+	AccountTrackingConfigs   []AccountTarget   `yaml:"account_targets"`
+	UnitTracking             bool              `yaml:"unit_tracking"`
+	UnitInterval             time.Duration     `yaml:"unit_interval"`
+	UnitSystemctlCommand     string            `yaml:"unit_systemctl_command"`
+	UnitTrackingConfigs      []UnitTarget      `yaml:"unit_targets"`
+	FirewallTracking         bool              `yaml:"firewall_tracking"`
+	FirewallInterval         time.Duration     `yaml:"firewall_interval"`
+	FirewallDumpCommand      string            `yaml:"firewall_dump_command"`
+	FirewallDumpArgs         []string          `yaml:"firewall_dump_args"`
+	FirewallBaselinePath     string            `yaml:"firewall_baseline_path"`
+	FirewallCommandId        int               `yaml:"firewall_command_id"`
+	DNSTracking              bool              `yaml:"dns_tracking"`
+	DNSInterval              time.Duration     `yaml:"dns_interval"`
+	DNSTrackingConfigs       []DNSTarget       `yaml:"dns_targets"`
+	PublicIPTracking         bool              `yaml:"public_ip_tracking"`
+	PublicIPInterval         time.Duration     `yaml:"public_ip_interval"`
+	PublicIPTrackingConfigs  []PublicIPTarget  `yaml:"public_ip_targets"`
+	CheckinTracking          bool              `yaml:"checkin_tracking"`
+	CheckinInterval          time.Duration     `yaml:"checkin_interval"`
+	CheckinDeadline          time.Duration     `yaml:"checkin_deadline"`
+	CheckinStatePath         string            `yaml:"checkin_state_path"`
+	CheckinPassphraseHash    string            `yaml:"checkin_passphrase_hash"`
+	CheckinFilePath          string            `yaml:"checkin_file_path"`
+	CheckinURL               string            `yaml:"checkin_url"`
+	CheckinSecret            string            `yaml:"checkin_secret"`
+	CheckinWarnings          []CheckinWarning  `yaml:"checkin_warnings"`
+	CheckinCommandId         int               `yaml:"checkin_command_id"`
+	Commands                 []Command         `yaml:"commands"`
 }
 
 // NewConfig Constructor for Config
@@ -133,32 +513,137 @@ func NewConfig() *Config {
 	webTrackingConfig := []WebTarget{{}}
 	timeTrackingConfig := []TimeTarget{{}}
 	IntervalTrackingConfigs := []IntervalTarget{{}}
+	loginTrackingConfigs := []LoginTarget{{}}
+	authTrackingConfigs := []AuthTarget{{}}
+	logTrackingConfigs := []LogTarget{{}}
+	kmsgTrackingConfigs := []KmsgTarget{{}}
+	sysctlTrackingConfigs := []SysctlTarget{{}}
+	bluetoothTrackingConfigs := []BluetoothTarget{{}}
+	devTrackingConfigs := []DevTarget{{}}
+	accountTrackingConfigs := []AccountTarget{{}}
+	unitTrackingConfigs := []UnitTarget{{}}
+	dnsTrackingConfigs := []DNSTarget{{}}
+	publicIPTrackingConfigs := []PublicIPTarget{{}}
 
 	return &Config{
-		Version:                 currentVersion,
-		FileLock:                true,
-		FileLockInverted:        false,
-		FileLockPath:            "/tmp/goTrack.lock",
-		FileLockDeletion:        true,
-		FileLockCreation:        true,
-		StartDelay:              3 * time.Second,
-		LogFile:                 "/var/log/goTrack.log",
-		OldLogs:                 1,
-		ExecOnError:             true,
-		USBTracking:             false,
-		USBInterval:             1000 * time.Millisecond,
-		IgnoredIDs:              nil,
-		PingTracking:            false,
-		PingInterval:            10000 * time.Millisecond,
-		PingTrackingConfigs:     pingTrackingConfig,
-		WebTracking:             false,
-		WebInterval:             60000 * time.Millisecond,
-		WebTrackingConfigs:      webTrackingConfig,
-		TimeTracking:            false,
-		TimeTrackingConfigs:     timeTrackingConfig,
-		IntervalTracking:        false,
-		IntervalTrackingConfigs: IntervalTrackingConfigs,
-		Commands:                commands,
+		Version:                  currentVersion,
+		FileLock:                 true,
+		FileLockInverted:         false,
+		FileLockPath:             "/tmp/goTrack.lock",
+		FileLockDeletion:         true,
+		FileLockCreation:         true,
+		StartDelay:               3 * time.Second,
+		LogFile:                  "/var/log/goTrack.log",
+		OldLogs:                  1,
+		ExecOnError:              true,
+		USBTracking:              false,
+		USBInterval:              1000 * time.Millisecond,
+		IgnoredIDs:               nil,
+		PingTracking:             false,
+		PingInterval:             10000 * time.Millisecond,
+		PingTrackingConfigs:      pingTrackingConfig,
+		PingGroups:               []PingGroup{},
+		PingHistorySize:          100,
+		PingExportPath:           "",
+		PingExportFormat:         PingExportCSV,
+		WebTracking:              false,
+		WebInterval:              60000 * time.Millisecond,
+		WebTrackingConfigs:       webTrackingConfig,
+		TimeTracking:             false,
+		TimeTrackingConfigs:      timeTrackingConfig,
+		IntervalTracking:         false,
+		IntervalTrackingConfigs:  IntervalTrackingConfigs,
+		LoginTracking:            false,
+		LoginInterval:            1000 * time.Millisecond,
+		LoginUtmpPath:            "/var/run/utmp",
+		LoginWtmpPath:            "/var/log/wtmp",
+		LoginTrackingConfigs:     loginTrackingConfigs,
+		AuthTracking:             false,
+		AuthInterval:             1000 * time.Millisecond,
+		AuthLogPaths:             []string{"/var/log/auth.log", "/var/log/secure"},
+		AuthTrackingConfigs:      authTrackingConfigs,
+		LogTracking:              false,
+		LogInterval:              1000 * time.Millisecond,
+		LogTrackingConfigs:       logTrackingConfigs,
+		KmsgTracking:             false,
+		KmsgPath:                 "/dev/kmsg",
+		KmsgTrackingConfigs:      kmsgTrackingConfigs,
+		ModuleTracking:           false,
+		ModuleInterval:           1000 * time.Millisecond,
+		ModulePath:               "/proc/modules",
+		ModuleBaseline:           true,
+		ModuleAllowed:            nil,
+		ModuleRequired:           nil,
+		ModuleDisableLoading:     false,
+		ModuleCommandId:          -1,
+		SysctlTracking:           false,
+		SysctlInterval:           10000 * time.Millisecond,
+		SysctlTrackingConfigs:    sysctlTrackingConfigs,
+		BootCheck:                false,
+		BootCmdlinePattern:       "",
+		BootCmdlineForbidden:     nil,
+		BootSecureBoot:           false,
+		BootKernelReleases:       nil,
+		BootCommandId:            -1,
+		RebootTracking:           false,
+		RebootStatePath:          "/var/lib/goTrack/boot.state",
+		RebootOnUncleanExit:      true,
+		RebootBootTimeTolerance:  10 * time.Second,
+		RebootCommandId:          -1,
+		ClockTracking:            false,
+		ClockInterval:            1000 * time.Millisecond,
+		ClockTolerance:           2 * time.Second,
+		ClockOnSuspend:           true,
+		ClockSuspendCommandId:    -1,
+		ClockOnHibernate:         true,
+		ClockHibernateCommandId:  -1,
+		ClockOnJump:              true,
+		ClockJumpCommandId:       -1,
+		DisplayTracking:          false,
+		DisplayInterval:          1000 * time.Millisecond,
+		DisplayAllowedEDIDs:      nil,
+		DisplayCommandId:         -1,
+		BluetoothTracking:        false,
+		BluetoothInterval:        1000 * time.Millisecond,
+		BluetoothTrackingConfigs: bluetoothTrackingConfigs,
+		DevTracking:              false,
+		DevPath:                  "/dev",
+		DevTrackingConfigs:       devTrackingConfigs,
+		AccountTracking:          false,
+		AccountInterval:          5000 * time.Millisecond,
+		AccountPasswdPath:        "/etc/passwd",
+		AccountShadowPath:        "/etc/shadow",
+		AccountGroupPath:         "/etc/group",
+		AccountSudoersPaths:      []string{"/etc/sudoers", "/etc/sudoers.d"},
+		AccountPrivilegedGroups:  []string{"root", "sudo", "wheel", "admin"},
+		AccountTrackingConfigs:   accountTrackingConfigs,
+		UnitTracking:             false,
+		UnitInterval:             5000 * time.Millisecond,
+		UnitSystemctlCommand:     "systemctl",
+		UnitTrackingConfigs:      unitTrackingConfigs,
+		FirewallTracking:         false,
+		FirewallInterval:         10000 * time.Millisecond,
+		FirewallDumpCommand:      "nft",
+		FirewallDumpArgs:         []string{"-j", "list", "ruleset"},
+		FirewallBaselinePath:     "/var/lib/goTrack/firewall.baseline",
+		FirewallCommandId:        -1,
+		DNSTracking:              false,
+		DNSInterval:              60000 * time.Millisecond,
+		DNSTrackingConfigs:       dnsTrackingConfigs,
+		PublicIPTracking:         false,
+		PublicIPInterval:         300000 * time.Millisecond,
+		PublicIPTrackingConfigs:  publicIPTrackingConfigs,
+		CheckinTracking:          false,
+		CheckinInterval:          60000 * time.Millisecond,
+		CheckinDeadline:          24 * time.Hour,
+		CheckinStatePath:         "/var/lib/goTrack/checkin",
+		CheckinPassphraseHash:    "",
+		CheckinFilePath:          "",
+		CheckinURL:               "",
+		CheckinSecret:            "",
+		CheckinWarnings:          []CheckinWarning{},
+		CheckinCommandId:         -1,
+		Commands:                 commands,
 	}
 }
 
@@ -184,13 +669,62 @@ func NewConfigFromFile(filename string) (*Config, error) {
 	if config.FileLockCreation && config.FileLockDeletion {
 		return nil, errors.New("ERROR: File lock creation and deletion enabled")
 	}
+	if err := config.validatePatterns(); err != nil {
+		return nil, err
+	}
+	if err := config.validatePingGroups(); err != nil {
+		return nil, err
+	}
+	if err := config.validateKmsgTargets(); err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
 
-// commandExecution runs any given command without any validation
-func (c Config) commandExecution(debug bool, command Command) uint8 {
-	output, err := exec.Command(command.Command, command.Args...).Output()
+// validatePatterns checks that all patterns of log targets compile, so invalid targets are rejected at load time instead of being ignored
+func (c *Config) validatePatterns() error {
+	for _, target := range c.LogTrackingConfigs {
+		if _, err := regexp.Compile(target.Pattern); err != nil {
+			return errors.New("ERROR: Invalid pattern for log file " + target.Path + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+// validateKmsgTargets checks facility, priority and pattern of all kmsg targets, so a typo does not silently drop a target
+func (c *Config) validateKmsgTargets() error {
+	for _, target := range c.KmsgTrackingConfigs {
+		if _, err := newKmsgRule(target); err != nil {
+			return errors.New("ERROR: Invalid kmsg target: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// validatePingGroups checks that all targets of ping groups refer to the key of a ping target, so a group never counts a target which is not pinged
+func (c *Config) validatePingGroups() error {
+	keys := make(map[string]bool)
+	for i := range c.PingTrackingConfigs {
+		keys[c.PingTrackingConfigs[i].key()] = true
+	}
+	for _, group := range c.PingGroups {
+		for _, target := range group.Targets {
+			if !keys[target] {
+				return errors.New("ERROR: Unknown target " + target + " in ping group " + group.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// commandExecution runs any given command without any validation. env is added to the environment of the command.
+func (c Config) commandExecution(debug bool, command Command, env ...string) uint8 {
+	cmd := exec.Command(command.Command, command.Args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	output, err := cmd.Output()
 	if outputString := string(output); len(outputString) > 0 {
 		c.log("Command: " + command.Command + " (CommandID: " + strconv.Itoa(command.Id) + ") Execution output: " + string(output))
 	}
@@ -206,6 +740,11 @@ func (c Config) commandExecution(debug bool, command Command) uint8 {
 
 // exec executes all commands that are enabled for the callee
 func (c Config) exec(debug bool, callee uint8, commandId int, noExec bool) (uint8, bool) {
+	return c.execWithEnv(debug, callee, commandId, noExec)
+}
+
+// execWithEnv executes all commands that are enabled for the callee. env holds "KEY=value" pairs describing the triggering event, which are passed to the commands.
+func (c Config) execWithEnv(debug bool, callee uint8, commandId int, noExec bool, env ...string) (uint8, bool) {
 	// If noExec is set nothing will be executed
 	if noExec {
 		c.log("Execution aborted due to \"NoExec\"")
@@ -222,47 +761,18 @@ func (c Config) exec(debug bool, callee uint8, commandId int, noExec bool) (uint
 		var lateCommands []Command
 		executed := NoExec
 		for _, command := range c.Commands {
-			if command.Id < 0 || command.Id == commandId {
-				if command.USB && callee == CalleeUSB {
-					if command.Late {
-						lateCommands = append(lateCommands, command)
-						continue
-					}
-					executed = consume(executed, c.commandExecution(debug, command))
-
-				} else if command.Ping && callee == CalleePing {
-					if command.Late {
-						lateCommands = append(lateCommands, command)
-						continue
-					}
-					executed = consume(executed, c.commandExecution(debug, command))
-
-				} else if command.Web && callee == CalleeWeb {
-					if command.Late {
-						lateCommands = append(lateCommands, command)
-						continue
-					}
-					executed = consume(executed, c.commandExecution(debug, command))
-				} else if command.Time && callee == CalleeTime {
-					if command.Late {
-						lateCommands = append(lateCommands, command)
-						continue
-					}
-					executed = consume(executed, c.commandExecution(debug, command))
-				} else if command.Interval && callee == CalleeInterval {
-					if command.Late {
-						lateCommands = append(lateCommands, command)
-						continue
-					}
-					executed = consume(executed, c.commandExecution(debug, command))
+			if (command.Id < 0 || command.Id == commandId) && command.isEnabledFor(callee) {
+				if command.Late {
+					lateCommands = append(lateCommands, command)
+					continue
 				}
+				executed = consume(executed, c.commandExecution(debug, command, env...))
 			}
-
 		}
 		// Execute late commands
 		late := false
 		for _, command := range lateCommands {
-			temp := c.commandExecution(debug, command)
+			temp := c.commandExecution(debug, command, env...)
 			executed = consume(executed, temp)
 			if temp == ExecSuc {
 				late = true
@@ -272,6 +782,59 @@ func (c Config) exec(debug bool, callee uint8, commandId int, noExec bool) (uint
 	}
 }
 
+// isEnabledFor checks if the command shall be executed on activation by the callee
+func (command Command) isEnabledFor(callee uint8) bool {
+	switch callee {
+	case CalleeUSB:
+		return command.USB
+	case CalleePing:
+		return command.Ping
+	case CalleeWeb:
+		return command.Web
+	case CalleeTime:
+		return command.Time
+	case CalleeInterval:
+		return command.Interval
+	case CalleeLogin:
+		return command.Login
+	case CalleeAuth:
+		return command.Auth
+	case CalleeLog:
+		return command.Log
+	case CalleeKmsg:
+		return command.Kmsg
+	case CalleeModule:
+		return command.Module
+	case CalleeSysctl:
+		return command.Sysctl
+	case CalleeBoot:
+		return command.Boot
+	case CalleeReboot:
+		return command.Reboot
+	case CalleeClock:
+		return command.Clock
+	case CalleeDisplay:
+		return command.Display
+	case CalleeBluetooth:
+		return command.Bluetooth
+	case CalleeDev:
+		return command.Dev
+	case CalleeAccount:
+		return command.Account
+	case CalleeUnit:
+		return command.Unit
+	case CalleeFirewall:
+		return command.Firewall
+	case CalleeDNS:
+		return command.DNS
+	case CalleePublicIP:
+		return command.PublicIP
+	case CalleeCheckin:
+		return command.Checkin
+	}
+	return false
+}
+
 // logErr is a little bit shorter and can be adapted in future
 func (c Config) logErr(err error) {
 	c.log(err.Error())
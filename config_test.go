@@ -186,6 +186,7 @@ func TestConfig_exec(t *testing.T) {
 	lsLateUSBCommand := Command{Command: "ls", Args: nil, Late: true, USB: true, Ping: false, Web: false, Id: -1}
 	lsLatePingCommand := Command{Command: "ls", Args: nil, Late: true, USB: false, Ping: true, Web: false, Id: -1}
 	lsLateWebCommand := Command{Command: "ls", Args: nil, Late: true, USB: false, Ping: false, Web: true, Id: -1}
+	lsLoginCommand := Command{Command: "ls", Args: nil, Late: false, Login: true, Id: -1}
 	type fields struct {
 		FileLock         bool
 		FileLockInverted bool
@@ -465,6 +466,20 @@ func TestConfig_exec(t *testing.T) {
 			want:   NoExec,
 			late:   false,
 		},
+		{
+			name:   "Execution, Login",
+			args:   args{callee: CalleeLogin, commandId: -1, noExec: false},
+			fields: fields{FileLock: false, FileLockInverted: false, FileLockPresent: false, Commands: []Command{lsLoginCommand}},
+			want:   ExecSuc,
+			late:   false,
+		},
+		{
+			name:   "No Execution, Wrong Callee: Login",
+			args:   args{callee: CalleeLogin, commandId: -1, noExec: false},
+			fields: fields{FileLock: false, FileLockInverted: false, FileLockPresent: false, Commands: []Command{lsAllCommand}},
+			want:   NoExec,
+			late:   false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -601,6 +616,8 @@ func TestNewConfig(t *testing.T) {
 }
 
 func TestNewConfigFromFile(t *testing.T) {
+	nine := int64(9)
+	loss := 9.5
 	type args struct {
 		filename string
 	}
@@ -630,15 +647,46 @@ func TestNewConfigFromFile(t *testing.T) {
 				PingTracking:     true,
 				PingInterval:     1 * time.Hour,
 				PingTrackingConfigs: []PingTarget{{
-					Target:      " ",
-					PingTimeout: 1 * time.Hour,
-					OnSuccess:   true,
-					RetryCount:  9,
-					RetryDelay:  1 * time.Hour,
-					CommandId:   -1,
+					Target:           " ",
+					PingTimeout:      1 * time.Hour,
+					OnSuccess:        true,
+					RetryCount:       9,
+					RetryDelay:       1 * time.Hour,
+					Count:            9,
+					PacketInterval:   1 * time.Hour,
+					MaxLoss:          &loss,
+					MaxAvgRtt:        1 * time.Hour,
+					MaxRtt:           1 * time.Hour,
+					MaxJitter:        1 * time.Hour,
+					Mode:             " ",
+					FailThreshold:    9,
+					RecoverThreshold: 9,
+					GroupOnly:        true,
+					Family:           " ",
+					Source:           " ",
+					Interface:        " ",
+					TTL:              9,
+					Size:             99,
+					Privileged:       true,
+					Type:             " ",
+					Port:             9,
+					Payload:          " ",
+					ExpectResponse:   true,
+					Interval:         9 * time.Hour,
+					CommandId:        -1,
 				}},
-				WebTracking: true,
-				WebInterval: 1 * time.Hour,
+				PingGroups: []PingGroup{{
+					Name:      " ",
+					Targets:   []string{" "},
+					Quorum:    9,
+					OnSuccess: true,
+					CommandId: -1,
+				}},
+				PingHistorySize:  9,
+				PingExportPath:   " ",
+				PingExportFormat: " ",
+				WebTracking:      true,
+				WebInterval:      1 * time.Hour,
 				WebTrackingConfigs: []WebTarget{{
 					Target:          " ",
 					Content:         " ",
@@ -648,8 +696,104 @@ func TestNewConfigFromFile(t *testing.T) {
 					OnHTTPSFails:    true,
 					RetryCount:      9,
 					RetryDelay:      1 * time.Hour,
+					Interval:        9 * time.Hour,
 					CommandId:       -1,
 				}},
+				ClockTracking:           true,
+				ClockInterval:           1 * time.Hour,
+				ClockTolerance:          1 * time.Hour,
+				ClockOnSuspend:          false,
+				ClockSuspendCommandId:   1,
+				ClockOnHibernate:        false,
+				ClockHibernateCommandId: 2,
+				ClockOnJump:             false,
+				ClockJumpCommandId:      3,
+				DisplayTracking:         true,
+				DisplayInterval:         1 * time.Hour,
+				DisplayAllowedEDIDs:     []string{" "},
+				DisplayCommandId:        -1,
+				BluetoothTracking:       true,
+				BluetoothInterval:       1 * time.Hour,
+				BluetoothTrackingConfigs: []BluetoothTarget{{
+					OnAdapterAdded: true,
+					OnPowered:      true,
+					OnPaired:       true,
+					OnConnected:    true,
+					AllowedDevices: []string{" "},
+					CommandId:      -1,
+				}},
+				PublicIPTracking: true,
+				PublicIPInterval: 1 * time.Hour,
+				PublicIPTrackingConfigs: []PublicIPTarget{{
+					Target:       " ",
+					AllowedCIDRs: []string{" "},
+					OnChange:     true,
+					Timeout:      1 * time.Hour,
+					RetryCount:   9,
+					RetryDelay:   1 * time.Hour,
+					CommandId:    -1,
+				}},
+				CheckinTracking:       true,
+				CheckinInterval:       1 * time.Hour,
+				CheckinDeadline:       9 * time.Hour,
+				CheckinStatePath:      " ",
+				CheckinPassphraseHash: " ",
+				CheckinFilePath:       " ",
+				CheckinURL:            " ",
+				CheckinSecret:         " ",
+				CheckinWarnings:       []CheckinWarning{{Remaining: 1 * time.Hour, CommandId: 9}},
+				CheckinCommandId:      -1,
+				DevTracking:           true,
+				DevPath:               " ",
+				DevTrackingConfigs: []DevTarget{{
+					Patterns:  []string{" "},
+					OnCreate:  true,
+					OnRemove:  true,
+					CommandId: -1,
+				}},
+				AccountTracking:         true,
+				AccountInterval:         1 * time.Hour,
+				AccountPasswdPath:       " ",
+				AccountShadowPath:       " ",
+				AccountGroupPath:        " ",
+				AccountSudoersPaths:     []string{" "},
+				AccountPrivilegedGroups: []string{" "},
+				AccountTrackingConfigs: []AccountTarget{{
+					Events:    []string{" "},
+					Users:     []string{" "},
+					CommandId: -1,
+				}},
+				UnitTracking:         true,
+				UnitInterval:         1 * time.Hour,
+				UnitSystemctlCommand: " ",
+				UnitTrackingConfigs: []UnitTarget{{
+					Unit:       " ",
+					States:     []string{" "},
+					RetryCount: 1,
+					RetryDelay: 1 * time.Hour,
+					CommandId:  -1,
+				}},
+				FirewallTracking:     true,
+				FirewallInterval:     1 * time.Hour,
+				FirewallDumpCommand:  " ",
+				FirewallDumpArgs:     []string{" "},
+				FirewallBaselinePath: " ",
+				FirewallCommandId:    -1,
+				DNSTracking:          true,
+				DNSInterval:          1 * time.Hour,
+				DNSTrackingConfigs: []DNSTarget{{
+					Name:           " ",
+					Type:           " ",
+					Resolver:       " ",
+					Timeout:        1 * time.Hour,
+					Expected:       []string{" "},
+					Content:        " ",
+					ContentIsExact: true,
+					OnFailure:      true,
+					RetryCount:     9,
+					RetryDelay:     1 * time.Hour,
+					CommandId:      -1,
+				}},
 				TimeTracking: true,
 				TimeTrackingConfigs: []TimeTarget{{
 					Timestamp: time.Date(2000, 01, 20, 12, 31, 00, 0, time.UTC),
@@ -664,16 +808,101 @@ func TestNewConfigFromFile(t *testing.T) {
 					ExecuteOnStart: true,
 					CommandId:      -1,
 				}},
+				LoginTracking: true,
+				LoginInterval: 1 * time.Hour,
+				LoginUtmpPath: " ",
+				LoginWtmpPath: " ",
+				LoginTrackingConfigs: []LoginTarget{{
+					Users:        []string{" "},
+					AllowedUsers: []string{" "},
+					TTYs:         []string{" "},
+					Hosts:        []string{" "},
+					RemoteOnly:   true,
+					LocalOnly:    true,
+					CommandId:    -1,
+				}},
+				AuthTracking: true,
+				AuthInterval: 1 * time.Hour,
+				AuthLogPaths: []string{" "},
+				AuthTrackingConfigs: []AuthTarget{{
+					Services:  []string{" "},
+					Threshold: 9,
+					Window:    1 * time.Hour,
+					CommandId: -1,
+				}},
+				LogTracking: true,
+				LogInterval: 1 * time.Hour,
+				LogTrackingConfigs: []LogTarget{{
+					Path:      " ",
+					Pattern:   " ",
+					Count:     9,
+					Window:    1 * time.Hour,
+					CommandId: -1,
+				}},
+				KmsgTracking: true,
+				KmsgPath:     " ",
+				KmsgTrackingConfigs: []KmsgTarget{{
+					Facility:  "kern",
+					Priority:  "err",
+					Pattern:   " ",
+					CommandId: -1,
+				}},
+				ModuleTracking:       true,
+				ModuleInterval:       1 * time.Hour,
+				ModulePath:           " ",
+				ModuleBaseline:       false,
+				ModuleAllowed:        []string{" "},
+				ModuleRequired:       []string{" "},
+				ModuleDisableLoading: true,
+				ModuleCommandId:      -1,
+				SysctlTracking:       true,
+				SysctlInterval:       1 * time.Hour,
+				SysctlTrackingConfigs: []SysctlTarget{{
+					Key:       " ",
+					Expected:  " ",
+					Min:       &nine,
+					Max:       &nine,
+					CommandId: -1,
+				}},
+				BootCheck:               true,
+				BootCmdlinePattern:      " ",
+				BootCmdlineForbidden:    []string{" "},
+				BootSecureBoot:          true,
+				BootKernelReleases:      []string{" "},
+				BootCommandId:           -1,
+				RebootTracking:          true,
+				RebootStatePath:         " ",
+				RebootOnUncleanExit:     true,
+				RebootBootTimeTolerance: 1 * time.Hour,
+				RebootCommandId:         -1,
 				Commands: []Command{{
-					Command:  " ",
-					Args:     []string{" "},
-					Late:     true,
-					USB:      true,
-					Ping:     true,
-					Web:      true,
-					Time:     true,
-					Interval: true,
-					Id:       -1,
+					Command:   " ",
+					Args:      []string{" "},
+					Late:      true,
+					USB:       true,
+					Ping:      true,
+					Web:       true,
+					Time:      true,
+					Interval:  true,
+					Login:     true,
+					Auth:      true,
+					Log:       true,
+					Kmsg:      true,
+					Module:    true,
+					Sysctl:    true,
+					Boot:      true,
+					Reboot:    true,
+					Clock:     true,
+					Display:   true,
+					Bluetooth: true,
+					Dev:       true,
+					Account:   true,
+					Unit:      true,
+					Firewall:  true,
+					DNS:       true,
+					PublicIP:  true,
+					Checkin:   true,
+					Id:        -1,
 				}},
 			},
 			wantErr: false,
@@ -866,3 +1095,67 @@ func TestConfig_createEmptyFileIfMissing(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_validatePatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []LogTarget
+		wantErr bool
+	}{
+		{name: "Default targets", targets: NewConfig().LogTrackingConfigs, wantErr: false},
+		{name: "Valid pattern", targets: []LogTarget{{Path: "/var/log/syslog", Pattern: `usb \d+-\d+`}}, wantErr: false},
+		{name: "Invalid pattern", targets: []LogTarget{{Path: "/var/log/syslog", Pattern: "error"}, {Path: "/var/log/syslog", Pattern: "("}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{LogTrackingConfigs: tt.targets}
+			if err := c.validatePatterns(); (err != nil) != tt.wantErr {
+				t.Errorf("validatePatterns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_validatePingGroups(t *testing.T) {
+	targets := []PingTarget{{Target: "192.0.2.1"}, {Target: "192.0.2.1", Type: PingTypeTCP, Port: 443}, {Target: "2001:db8::1", Type: PingTypeUDP, Port: 53}}
+	tests := []struct {
+		name    string
+		groups  []PingGroup
+		wantErr bool
+	}{
+		{name: "No groups", groups: nil, wantErr: false},
+		{name: "Known keys", groups: []PingGroup{{Name: "all", Targets: []string{"192.0.2.1", "tcp://192.0.2.1:443", "udp://[2001:db8::1]:53"}}}, wantErr: false},
+		{name: "Address of TCP target", groups: []PingGroup{{Name: "web", Targets: []string{"2001:db8::1"}}}, wantErr: true},
+		{name: "Wrong port", groups: []PingGroup{{Name: "web", Targets: []string{"tcp://192.0.2.1:80"}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{PingTrackingConfigs: targets, PingGroups: tt.groups}
+			if err := c.validatePingGroups(); (err != nil) != tt.wantErr {
+				t.Errorf("validatePingGroups() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_validateKmsgTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []KmsgTarget
+		wantErr bool
+	}{
+		{name: "Default targets", targets: NewConfig().KmsgTrackingConfigs, wantErr: false},
+		{name: "Valid target", targets: []KmsgTarget{{Facility: "kern", Priority: "err", Pattern: `usb \d+`}}, wantErr: false},
+		{name: "Unknown facility", targets: []KmsgTarget{{Facility: "kernel"}}, wantErr: true},
+		{name: "Unknown priority", targets: []KmsgTarget{{Priority: "error"}}, wantErr: true},
+		{name: "Invalid pattern", targets: []KmsgTarget{{Pattern: "usb"}, {Pattern: "("}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{KmsgTrackingConfigs: tt.targets}
+			if err := c.validateKmsgTargets(); (err != nil) != tt.wantErr {
+				t.Errorf("validateKmsgTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const DevCreated = "created"
+const DevRemoved = "removed"
+
+// devWatchMask selects the inotify events for nodes appearing or disappearing
+const devWatchMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_TO | unix.IN_MOVED_FROM
+
+// DevTracker represents the device node tracking service
+type DevTracker struct {
+	Config *Config
+	fd     int
+	// watches maps inotify watch descriptors to the watched directories
+	watches map[int32]string
+	// read reads events from the inotify instance
+	read func(fd int, buffer []byte) (int, error)
+}
+
+// NewDevTracker creates a new DevTracker instance
+func NewDevTracker(config *Config) *DevTracker {
+	return &DevTracker{
+		Config:  config,
+		fd:      -1,
+		watches: make(map[int32]string),
+		read:    unix.Read,
+	}
+}
+
+// InitDevices creates the inotify instance and watches DevPath and all subdirectories
+func (d *DevTracker) InitDevices(debug bool) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC)
+	if err != nil {
+		return err
+	}
+	d.fd = fd
+	if err := d.addWatches(d.Config.DevPath, debug, nil); err != nil {
+		d.close()
+		return err
+	}
+	return nil
+}
+
+// close closes the inotify instance and removes all watches
+func (d *DevTracker) close() {
+	if d.fd >= 0 {
+		_ = unix.Close(d.fd)
+	}
+	d.fd = -1
+	d.watches = make(map[int32]string)
+}
+
+// devReinitDelay is the time to wait before recreating the inotify instance after a read error
+var devReinitDelay = 5 * time.Second
+
+// TrackDevices reads inotify events and blocks until the events end. Meant to be executed async.
+// On read errors the inotify instance and its watches are recreated, nodes changing in between are missed. Returns the number of executions.
+func (d *DevTracker) TrackDevices(noExec, debug bool) uint {
+	var counter uint = 0
+	if d.fd < 0 {
+		if err := d.InitDevices(debug); err != nil {
+			d.Config.logErr(err)
+			if d.Config.ExecOnError {
+				d.Config.exec(debug, CalleeDev, -1, noExec)
+			}
+			return counter
+		}
+	}
+	defer d.close()
+	for {
+		executions, err := d.readEvents(noExec, debug)
+		counter += executions
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return counter
+		}
+		d.Config.logErr(err)
+		if d.Config.ExecOnError {
+			d.Config.exec(debug, CalleeDev, -1, noExec)
+		}
+		d.close()
+		for {
+			time.Sleep(devReinitDelay)
+			if err = d.InitDevices(debug); err == nil {
+				break
+			}
+			d.Config.logErr(err)
+		}
+		d.Config.log("Recreated device node watches after read error")
+	}
+}
+
+// readEvents blocks until events are available and handles them. Returns the number of executions and io.EOF if no
+// more events can be read.
+func (d *DevTracker) readEvents(noExec, debug bool) (uint, error) {
+	// Large enough for many events with names up to NAME_MAX
+	buffer := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	n, err := d.read(d.fd, buffer)
+	if errors.Is(err, unix.EINTR) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, io.EOF
+	}
+
+	var counter uint = 0
+	for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+		event := (*unix.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+		nameBytes := buffer[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+		offset += unix.SizeofInotifyEvent + int(event.Len)
+
+		if event.Mask&unix.IN_Q_OVERFLOW != 0 {
+			d.Config.log("Device node events lost as inotify queue overflowed")
+			continue
+		}
+		if event.Mask&unix.IN_IGNORED != 0 {
+			// The watched directory was removed
+			delete(d.watches, event.Wd)
+			continue
+		}
+		dir, ok := d.watches[event.Wd]
+		if !ok {
+			continue
+		}
+		path := filepath.Join(dir, cString(nameBytes))
+
+		if event.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+			counter += d.handleEvent(noExec, debug, path, DevCreated)
+			if event.Mask&unix.IN_ISDIR != 0 {
+				// Nodes may be created before the watch is added, so existing entries are reported as created
+				err := d.addWatches(path, debug, func(entry string) {
+					counter += d.handleEvent(noExec, debug, entry, DevCreated)
+				})
+				if err != nil {
+					d.Config.logErr(err)
+				}
+			}
+		} else if event.Mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0 {
+			counter += d.handleEvent(noExec, debug, path, DevRemoved)
+		}
+	}
+	return counter, nil
+}
+
+// handleEvent executes the commands of all targets matching the path and event. Returns the number of executions.
+func (d *DevTracker) handleEvent(noExec, debug bool, path, event string) uint {
+	var counter uint = 0
+	for _, target := range d.Config.DevTrackingConfigs {
+		if !target.matches(path, event) {
+			continue
+		}
+		counter++
+		d.Config.log("Device node " + event + ": " + path)
+		d.Config.execWithEnv(debug, CalleeDev, target.CommandId, noExec, "GOTRACK_DEV_EVENT="+event, "GOTRACK_DEV_PATH="+path)
+	}
+	if debug && counter == 0 {
+		d.Config.log("Device node " + event + " not matching: " + path)
+	}
+	return counter
+}
+
+// matches checks if the target reacts to the event on path
+func (t DevTarget) matches(path, event string) bool {
+	if event == DevCreated && !t.OnCreate || event == DevRemoved && !t.OnRemove {
+		return false
+	}
+	return matchesAny(t.Patterns, path)
+}
+
+// addWatches adds watches for root and all its subdirectories. If found is set it is called for every entry below root.
+func (d *DevTracker) addWatches(root string, debug bool, found func(path string)) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Entries may disappear while walking
+			if debug {
+				d.Config.logErr(err)
+			}
+			return nil
+		}
+		if path != root && found != nil {
+			found(path)
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		wd, err := unix.InotifyAddWatch(d.fd, path, devWatchMask)
+		if err != nil {
+			if path == root {
+				return err
+			}
+			d.Config.logErr(err)
+			return nil
+		}
+		d.watches[int32(wd)] = path
+		if debug {
+			d.Config.log("Watching device directory: " + path + " (" + strconv.Itoa(wd) + ")")
+		}
+		return nil
+	})
+}
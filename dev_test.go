@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestDevTracker_readEvents(t *testing.T) {
+	root := t.TempDir()
+	config := NewConfig()
+	config.LogFile = ""
+	config.DevPath = root
+	config.DevTrackingConfigs = []DevTarget{
+		{Patterns: []string{filepath.Join(root, "ttyUSB*"), filepath.Join(root, "bus", "*"), filepath.Join(root, "bus", "*", "*")}, OnCreate: true, CommandId: -1},
+		{Patterns: []string{filepath.Join(root, "ttyUSB*")}, OnRemove: true, CommandId: -1},
+	}
+	if err := os.Mkdir(filepath.Join(root, "bus"), 0700); err != nil {
+		t.Fatalf("Error creating directory: %v", err)
+	}
+
+	d := NewDevTracker(config)
+	if err := d.InitDevices(false); err != nil {
+		t.Fatalf("InitDevices() error = %v", err)
+	}
+	defer d.close()
+
+	create := func(path string) func() {
+		return func() {
+			if err := os.WriteFile(path, nil, 0600); err != nil {
+				t.Fatalf("Error creating node: %v", err)
+			}
+		}
+	}
+	steps := []struct {
+		name   string
+		action func()
+		want   uint
+	}{
+		{name: "Serial adapter created", action: create(filepath.Join(root, "ttyUSB0")), want: 1},
+		{name: "Other node created", action: create(filepath.Join(root, "null")), want: 0},
+		{name: "Node in watched subdirectory", action: create(filepath.Join(root, "bus", "usb")), want: 1},
+		{name: "Directory with node created", action: func() {
+			if err := os.MkdirAll(filepath.Join(root, "bus", "input"), 0700); err != nil {
+				t.Fatalf("Error creating directory: %v", err)
+			}
+			create(filepath.Join(root, "bus", "input", "event0"))()
+		}, want: 2},
+		{name: "Node in new subdirectory", action: create(filepath.Join(root, "bus", "input", "event1")), want: 1},
+		{name: "Serial adapter removed", action: func() {
+			if err := os.Remove(filepath.Join(root, "ttyUSB0")); err != nil {
+				t.Fatalf("Error removing node: %v", err)
+			}
+		}, want: 1},
+	}
+	for _, step := range steps {
+		step.action()
+		got, err := d.readEvents(true, false)
+		if err != nil {
+			t.Fatalf("%s: readEvents() error = %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: readEvents() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestDevTracker_TrackDevices_reinit(t *testing.T) {
+	reinitDelay := devReinitDelay
+	devReinitDelay = time.Millisecond
+	defer func() {
+		devReinitDelay = reinitDelay
+	}()
+	root := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "executed")
+	config := NewConfig()
+	config.LogFile = ""
+	config.ExecOnError = true
+	config.DevPath = root
+	config.Commands = []Command{{Command: "touch", Args: []string{marker}, Dev: true, Id: -1}}
+	config.DevTrackingConfigs = []DevTarget{{Patterns: []string{filepath.Join(root, "ttyUSB*")}, OnCreate: true, CommandId: -1}}
+
+	// The first read fails, the recreated watches report the node, then the events end
+	var fds []int
+	d := NewDevTracker(config)
+	d.read = func(fd int, buffer []byte) (int, error) {
+		fds = append(fds, fd)
+		switch len(fds) {
+		case 1:
+			return 0, errors.New("read failed")
+		case 2:
+			if err := os.WriteFile(filepath.Join(root, "ttyUSB0"), nil, 0600); err != nil {
+				t.Fatalf("Error creating node: %v", err)
+			}
+			return unix.Read(fd, buffer)
+		}
+		return 0, nil
+	}
+	if err := d.InitDevices(false); err != nil {
+		t.Fatalf("InitDevices() error = %v", err)
+	}
+
+	if got := d.TrackDevices(false, false); got != 1 {
+		t.Errorf("TrackDevices() = %v, want 1", got)
+	}
+	if len(fds) != 3 || fds[1] < 0 || fds[1] != fds[2] {
+		t.Errorf("TrackDevices() read from %v, want a recreated instance after the error", fds)
+	}
+	if !fileExists(marker) {
+		t.Error("TrackDevices() did not execute on read error")
+	}
+	if d.fd >= 0 {
+		t.Error("TrackDevices() did not close the inotify instance")
+	}
+}
+
+func TestDevTarget_matches(t *testing.T) {
+	target := DevTarget{Patterns: []string{"/dev/ttyUSB*", "/dev/video*"}, OnCreate: true}
+	tests := []struct {
+		name  string
+		path  string
+		event string
+		want  bool
+	}{
+		{name: "Serial created", path: "/dev/ttyUSB0", event: DevCreated, want: true},
+		{name: "Video created", path: "/dev/video2", event: DevCreated, want: true},
+		{name: "Serial removed", path: "/dev/ttyUSB0", event: DevRemoved, want: false},
+		{name: "Other created", path: "/dev/null", event: DevCreated, want: false},
+		{name: "Subdirectory not matching", path: "/dev/ttyUSB/0", event: DevCreated, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := target.matches(tt.path, tt.event); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
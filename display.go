@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const drmPath = "/sys/class/drm"
+
+// displayConnector represents the state of a DRM connector like "card0-HDMI-A-1"
+type displayConnector struct {
+	connected bool
+	// edid is the sha256 hash of the EDID of the connected monitor, empty if none
+	edid string
+}
+
+// DisplayTracker represents the display connector tracking service
+type DisplayTracker struct {
+	Config           *Config
+	cachedConnectors map[string]displayConnector
+	// root is the directory holding the DRM connectors
+	root string
+}
+
+// NewDisplayTracker creates a new DisplayTracker instance
+func NewDisplayTracker(config *Config) *DisplayTracker {
+	return &DisplayTracker{
+		Config: config,
+		root:   drmPath,
+	}
+}
+
+// InitDisplays initializes the state of all connectors
+func (d *DisplayTracker) InitDisplays(verbose, debug bool) {
+	connectors, err := d.readConnectors()
+	if err != nil {
+		d.Config.logErr(err)
+	}
+	d.cachedConnectors = connectors
+	if verbose {
+		fmt.Println("Connectors at start:\nConnector\t\tStatus\tEDID")
+		for name, connector := range d.cachedConnectors {
+			status := "disconnected"
+			if connector.connected {
+				status = "connected"
+			}
+			fmt.Println(name + "\t\t" + status + "\t" + connector.edid)
+		}
+	}
+}
+
+// TrackDisplays tracks connector changes. Meant to be executed periodically. Returns the number of executions.
+func (d *DisplayTracker) TrackDisplays(noExec, debug bool) uint {
+	current, err := d.readConnectors()
+	if err != nil {
+		d.Config.logErr(err)
+		if d.Config.ExecOnError {
+			d.Config.exec(debug, CalleeDisplay, d.Config.DisplayCommandId, noExec)
+		}
+		return 0
+	}
+	if d.cachedConnectors == nil {
+		d.cachedConnectors = make(map[string]displayConnector)
+	}
+
+	var counter uint = 0
+	for name, connector := range current {
+		cached := d.cachedConnectors[name]
+		event := ""
+		if connector.connected && !cached.connected {
+			event = "connected"
+		} else if connector.connected && connector.edid != cached.edid {
+			event = "edid_changed"
+		} else if !connector.connected && cached.connected && debug {
+			d.Config.log("Display disconnected: " + name)
+		}
+		d.cachedConnectors[name] = connector
+		if len(event) == 0 {
+			continue
+		}
+		if has(d.Config.DisplayAllowedEDIDs, connector.edid) {
+			if debug {
+				d.Config.log("Allowed display " + event + ": " + name + " EDID: " + connector.edid)
+			}
+			continue
+		}
+		counter++
+		d.Config.log("Display " + event + ": " + name + " EDID: " + connector.edid)
+		d.Config.execWithEnv(debug, CalleeDisplay, d.Config.DisplayCommandId, noExec,
+			"GOTRACK_DISPLAY_CONNECTOR="+name,
+			"GOTRACK_DISPLAY_EVENT="+event,
+			"GOTRACK_DISPLAY_EDID="+connector.edid,
+		)
+	}
+	return counter
+}
+
+// readConnectors reads the status and EDID hash of all connectors
+func (d *DisplayTracker) readConnectors() (map[string]displayConnector, error) {
+	paths, err := filepath.Glob(filepath.Join(d.root, "card*-*", "status"))
+	if err != nil {
+		return nil, err
+	}
+	connectors := make(map[string]displayConnector)
+	for _, statusPath := range paths {
+		status, err := os.ReadFile(statusPath)
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.Dir(statusPath)
+		connector := displayConnector{connected: strings.TrimSpace(string(status)) == "connected"}
+		if connector.connected {
+			// Some drivers do not provide the EDID
+			if edid, err := os.ReadFile(filepath.Join(dir, "edid")); err == nil && len(edid) > 0 {
+				sum := sha256.Sum256(edid)
+				connector.edid = hex.EncodeToString(sum[:])
+			}
+		}
+		connectors[filepath.Base(dir)] = connector
+	}
+	return connectors, nil
+}
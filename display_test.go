@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConnector writes the status and EDID of a DRM connector below root
+func writeConnector(t *testing.T, root, name, status, edid string) {
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("Error creating connector: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "status"), []byte(status+"\n"), 0600); err != nil {
+		t.Fatalf("Error writing status: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "edid"), []byte(edid), 0600); err != nil {
+		t.Fatalf("Error writing edid: %v", err)
+	}
+}
+
+func TestDisplayTracker_TrackDisplays(t *testing.T) {
+	root := t.TempDir()
+	known := sha256.Sum256([]byte("known monitor"))
+	config := NewConfig()
+	config.LogFile = ""
+	config.DisplayAllowedEDIDs = []string{hex.EncodeToString(known[:])}
+	writeConnector(t, root, "card0-eDP-1", "connected", "internal panel")
+	writeConnector(t, root, "card0-HDMI-A-1", "disconnected", "")
+
+	d := NewDisplayTracker(config)
+	d.root = root
+	d.InitDisplays(false, false)
+
+	steps := []struct {
+		name   string
+		action func()
+		want   uint
+	}{
+		{name: "Unchanged", action: func() {}, want: 0},
+		{name: "Known monitor connected", action: func() { writeConnector(t, root, "card0-HDMI-A-1", "connected", "known monitor") }, want: 0},
+		{name: "Monitor replaced", action: func() { writeConnector(t, root, "card0-HDMI-A-1", "connected", "capture device") }, want: 1},
+		{name: "Disconnected", action: func() { writeConnector(t, root, "card0-HDMI-A-1", "disconnected", "") }, want: 0},
+		{name: "Capture device connected", action: func() { writeConnector(t, root, "card0-HDMI-A-1", "connected", "capture device") }, want: 1},
+		{name: "New connector", action: func() { writeConnector(t, root, "card1-DP-1", "connected", "other") }, want: 1},
+	}
+	for _, step := range steps {
+		step.action()
+		if got := d.TrackDisplays(true, false); got != step.want {
+			t.Errorf("%s: TrackDisplays() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const DNSNoExec uint8 = 0
+const DNSUnexpected uint8 = 1
+const DNSCont uint8 = 2
+const DNSContExact uint8 = 3
+const DNSFailed uint8 = 4
+
+// DNSTracker represents the DNS resolution tracking service
+type DNSTracker struct {
+	Config *Config
+}
+
+// NewDNSTracker returns a new DNSTracker with given Config
+func NewDNSTracker(config *Config) *DNSTracker {
+	return &DNSTracker{Config: config}
+}
+
+// TrackDNSTargets starts async resolution of all targets. Meant to be executed periodically.
+func (d *DNSTracker) TrackDNSTargets(noExec, debug bool) uint {
+	var counter uint = 0
+	for _, target := range d.Config.DNSTrackingConfigs {
+		go d.trackDNSTarget(noExec, debug, target)
+		counter++
+	}
+	return counter
+}
+
+// trackDNSTarget resolves the target with retries and checks the answers against the expected addresses and content
+func (d *DNSTracker) trackDNSTarget(noExec, debug bool, target DNSTarget) uint8 {
+	var answers []string
+	var err error
+	for i := 0; i <= target.RetryCount; i++ {
+		if debug && i > 0 {
+			d.Config.log("Retrying DNS lookup: " + target.Name)
+		}
+		if answers, err = target.lookup(); err == nil {
+			break
+		}
+		d.Config.logErr(err)
+		time.Sleep(target.RetryDelay)
+	}
+	if debug && err == nil {
+		d.Config.log("DNS " + target.recordType() + " " + target.Name + ": " + strings.Join(answers, ","))
+	}
+
+	returnValue := DNSNoExec
+	if err != nil {
+		if target.OnFailure {
+			returnValue = DNSFailed
+		}
+	} else {
+		// Check for addresses outside the expected set. No answer is not expected either.
+		if len(target.Expected) > 0 {
+			if len(answers) == 0 {
+				returnValue = DNSUnexpected
+			}
+			for _, answer := range answers {
+				if !target.isExpected(answer) {
+					returnValue = DNSUnexpected
+					break
+				}
+			}
+		}
+		// Check for Content match
+		if len(target.Content) > 0 {
+			for _, answer := range answers {
+				if target.ContentIsExact && answer == target.Content {
+					returnValue = DNSContExact
+				} else if !target.ContentIsExact && strings.Contains(answer, target.Content) {
+					returnValue = DNSCont
+				}
+			}
+		}
+	}
+
+	if returnValue != DNSNoExec {
+		d.Config.log("Executing on DNS tracking for: " + target.Name + " answers: " + strings.Join(answers, ","))
+		d.Config.execWithEnv(debug, CalleeDNS, target.CommandId, noExec,
+			"GOTRACK_DNS_NAME="+target.Name,
+			"GOTRACK_DNS_TYPE="+target.recordType(),
+			"GOTRACK_DNS_ANSWERS="+strings.Join(answers, ","),
+		)
+	}
+	return returnValue
+}
+
+// isExpected checks if the answer is in Expected. Addresses are compared parsed, so every spelling of an IPv6 address matches.
+func (t DNSTarget) isExpected(answer string) bool {
+	ip := net.ParseIP(answer)
+	for _, expected := range t.Expected {
+		if (ip != nil && ip.Equal(net.ParseIP(expected))) || answer == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// recordType returns the upper case record type, "A" if not set
+func (t DNSTarget) recordType() string {
+	if len(t.Type) == 0 {
+		return "A"
+	}
+	return strings.ToUpper(t.Type)
+}
+
+// lookup resolves the target. The system resolver is used if no resolver is configured.
+func (t DNSTarget) lookup() ([]string, error) {
+	timeout := t.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	var qtype dnsmessage.Type
+	network := "ip4"
+	switch t.recordType() {
+	case "A":
+		qtype = dnsmessage.TypeA
+	case "AAAA":
+		qtype = dnsmessage.TypeAAAA
+		network = "ip6"
+	case "TXT":
+		qtype = dnsmessage.TypeTXT
+	default:
+		return nil, errors.New("unknown DNS record type: " + t.Type)
+	}
+	if len(t.Resolver) > 0 {
+		server := t.Resolver
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		return queryDNS(server, t.Name, qtype, timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if qtype == dnsmessage.TypeTXT {
+		return net.DefaultResolver.LookupTXT(ctx, t.Name)
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, t.Name)
+	var answers []string
+	for _, ip := range ips {
+		answers = append(answers, ip.String())
+	}
+	return answers, err
+}
+
+// queryDNS sends a query for name to server via UDP and repeats it via TCP if the response is truncated
+func queryDNS(server, name string, qtype dnsmessage.Type, timeout time.Duration) ([]string, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.UintN(1 << 16))
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := exchangeDNS("udp", server, packed, timeout)
+	if err == nil && response.Truncated {
+		response, err = exchangeDNS("tcp", server, packed, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response.ID != id || !response.Response {
+		return nil, errors.New("invalid DNS response from " + server)
+	}
+	if response.RCode != dnsmessage.RCodeSuccess {
+		return nil, errors.New("DNS lookup of " + name + " failed: " + response.RCode.String())
+	}
+
+	var answers []string
+	for _, answer := range response.Answers {
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			answers = append(answers, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			answers = append(answers, net.IP(body.AAAA[:]).String())
+		case *dnsmessage.TXTResource:
+			answers = append(answers, strings.Join(body.TXT, ""))
+		}
+	}
+	return answers, nil
+}
+
+// exchangeDNS sends the packed query and reads the response. Messages via TCP are prefixed with their length.
+func exchangeDNS(network, server string, packed []byte, timeout time.Duration) (*dnsmessage.Message, error) {
+	conn, err := net.DialTimeout(network, server, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var buffer []byte
+	if network == "tcp" {
+		if _, err := conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(packed)))); err != nil {
+			return nil, err
+		}
+		if _, err := conn.Write(packed); err != nil {
+			return nil, err
+		}
+		length := make([]byte, 2)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return nil, err
+		}
+		buffer = make([]byte, binary.BigEndian.Uint16(length))
+		if _, err := io.ReadFull(conn, buffer); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(packed); err != nil {
+			return nil, err
+		}
+		buffer = make([]byte, 65535)
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		buffer = buffer[:n]
+	}
+
+	response := &dnsmessage.Message{}
+	if err := response.Unpack(buffer); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
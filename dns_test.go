@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startDNSServer starts a local UDP DNS server answering with the given records. Unknown names are answered with NXDOMAIN.
+func startDNSServer(t *testing.T, records map[string][]dnsmessage.Resource) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	go func() {
+		buffer := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buffer[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			question := query.Questions[0]
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionDesired: query.RecursionDesired},
+				Questions: query.Questions,
+			}
+			resources, found := records[question.Name.String()]
+			if !found {
+				response.RCode = dnsmessage.RCodeNameError
+			}
+			for _, resource := range resources {
+				if resource.Header.Type == question.Type {
+					resource.Header.Name = question.Name
+					resource.Header.Class = dnsmessage.ClassINET
+					response.Answers = append(response.Answers, resource)
+				}
+			}
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSTracker_trackDNSTarget(t *testing.T) {
+	server := startDNSServer(t, map[string][]dnsmessage.Resource{
+		"host.example.": {
+			{Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeA}, Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}},
+			{Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeAAAA}, Body: &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}}},
+		},
+		"switch.example.": {
+			{Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeTXT}, Body: &dnsmessage.TXTResource{TXT: []string{"v=1 goTrack-", "kill"}}},
+		},
+	})
+	tests := []struct {
+		name   string
+		target DNSTarget
+		want   uint8
+	}{
+		{
+			name:   "expected address",
+			target: DNSTarget{Name: "host.example", Resolver: server, Expected: []string{"192.0.2.1"}},
+			want:   DNSNoExec,
+		},
+		{
+			name:   "unexpected address",
+			target: DNSTarget{Name: "host.example", Resolver: server, Expected: []string{"192.0.2.2"}},
+			want:   DNSUnexpected,
+		},
+		{
+			name:   "expected IPv6 address",
+			target: DNSTarget{Name: "host.example", Type: "aaaa", Resolver: server, Expected: []string{"2001:db8::1"}},
+			want:   DNSNoExec,
+		},
+		{
+			name:   "expected IPv6 address in long form",
+			target: DNSTarget{Name: "host.example", Type: "aaaa", Resolver: server, Expected: []string{"2001:0db8:0000::0001"}},
+			want:   DNSNoExec,
+		},
+		{
+			name:   "no answer with expected addresses",
+			target: DNSTarget{Name: "switch.example", Resolver: server, Expected: []string{"192.0.2.1"}},
+			want:   DNSUnexpected,
+		},
+		{
+			name:   "no answer without expected addresses",
+			target: DNSTarget{Name: "switch.example", Resolver: server},
+			want:   DNSNoExec,
+		},
+		{
+			name:   "no expected addresses",
+			target: DNSTarget{Name: "host.example", Resolver: server},
+			want:   DNSNoExec,
+		},
+		{
+			name:   "TXT contains token",
+			target: DNSTarget{Name: "switch.example", Type: "TXT", Resolver: server, Content: "goTrack-kill"},
+			want:   DNSCont,
+		},
+		{
+			name:   "TXT exact token",
+			target: DNSTarget{Name: "switch.example", Type: "TXT", Resolver: server, Content: "v=1 goTrack-kill", ContentIsExact: true},
+			want:   DNSContExact,
+		},
+		{
+			name:   "TXT not exact",
+			target: DNSTarget{Name: "switch.example", Type: "TXT", Resolver: server, Content: "goTrack-kill", ContentIsExact: true},
+			want:   DNSNoExec,
+		},
+		{
+			name:   "NXDOMAIN on failure",
+			target: DNSTarget{Name: "missing.example", Resolver: server, OnFailure: true, RetryCount: 1, RetryDelay: time.Millisecond},
+			want:   DNSFailed,
+		},
+		{
+			name:   "NXDOMAIN without on failure",
+			target: DNSTarget{Name: "missing.example", Resolver: server},
+			want:   DNSNoExec,
+		},
+		{
+			name:   "unknown record type",
+			target: DNSTarget{Name: "host.example", Type: "MX", Resolver: server, OnFailure: true},
+			want:   DNSFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDNSTracker(&Config{})
+			if got := d.trackDNSTarget(true, false, tt.target); got != tt.want {
+				t.Errorf("trackDNSTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
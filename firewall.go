@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+const FirewallOk uint8 = 0
+const FirewallDrift uint8 = 1
+const FirewallExec uint8 = 2
+const FirewallErr uint8 = 3
+const FirewallNoBaseline uint8 = 4
+
+// firewallCounters matches the packet and byte counters of iptables-save -c like "[12:3456]"
+var firewallCounters = regexp.MustCompile(`\[\d+:\d+\]`)
+
+// firewallVolatileKeys holds keys of nft JSON output changing without a change of the ruleset
+var firewallVolatileKeys = []string{"handle", "packets", "bytes"}
+
+// FirewallTracker represents the firewall ruleset drift tracking service
+type FirewallTracker struct {
+	Config   *Config
+	baseline []string
+	// initialized is true once the baseline has been loaded or stored
+	initialized bool
+	// reported is the hash of the last drifted ruleset commands were executed for
+	reported string
+	// dump returns the current ruleset
+	dump func() ([]byte, error)
+}
+
+// NewFirewallTracker creates a new FirewallTracker instance
+func NewFirewallTracker(config *Config) *FirewallTracker {
+	f := &FirewallTracker{Config: config}
+	f.dump = f.dumpCommand
+	return f
+}
+
+// InitFirewall loads the baseline from FirewallBaselinePath. If it does not exist, the current ruleset is stored as baseline.
+// An empty ruleset is not stored, as the firewall is usually not loaded yet.
+func (f *FirewallTracker) InitFirewall(verbose, debug bool) error {
+	data, err := os.ReadFile(f.Config.FirewallBaselinePath)
+	if err == nil {
+		f.baseline = splitFirewallLines(string(data))
+	} else if os.IsNotExist(err) {
+		if f.baseline, err = f.readRuleset(); err != nil {
+			return err
+		}
+		if len(f.baseline) == 0 {
+			return errors.New("firewall ruleset is empty, baseline not stored")
+		}
+		createPath(f.Config.FirewallBaselinePath)
+		if err := os.WriteFile(f.Config.FirewallBaselinePath, []byte(strings.Join(f.baseline, "\n")+"\n"), 0600); err != nil {
+			return err
+		}
+		f.Config.printAndLog("Stored firewall baseline at: " + f.Config.FirewallBaselinePath)
+	} else {
+		return err
+	}
+	f.initialized = true
+	if verbose || debug {
+		f.Config.printAndLog("Firewall baseline hash: " + hashFirewallLines(f.baseline))
+	}
+	return nil
+}
+
+// TrackFirewall compares the current ruleset with the baseline. Meant to be executed periodically.
+// Commands are executed once for every differing ruleset, not while it stays the same.
+// Without a baseline, like if the firewall was not loaded at start, the initialization is retried and nothing is executed.
+func (f *FirewallTracker) TrackFirewall(noExec, debug bool) uint8 {
+	if !f.initialized {
+		if err := f.InitFirewall(false, debug); err != nil {
+			f.Config.log("Firewall baseline not available, retrying")
+			f.Config.logErr(err)
+			return FirewallNoBaseline
+		}
+	}
+	current, err := f.readRuleset()
+	if err != nil {
+		f.Config.logErr(err)
+		if f.Config.ExecOnError {
+			f.Config.exec(debug, CalleeFirewall, f.Config.FirewallCommandId, noExec)
+			return FirewallExec
+		}
+		return FirewallErr
+	}
+	hash := hashFirewallLines(current)
+	if debug {
+		f.Config.log("Firewall ruleset hash: " + hash)
+	}
+
+	if hash == hashFirewallLines(f.baseline) {
+		if len(f.reported) > 0 {
+			f.Config.log("Firewall ruleset restored to baseline")
+			f.reported = ""
+		}
+		return FirewallOk
+	}
+	if hash == f.reported {
+		return FirewallDrift
+	}
+	f.reported = hash
+	diff := diffFirewallLines(f.baseline, current)
+	f.Config.log("Firewall ruleset drifted from baseline, hash: " + hash + "\n" + diff)
+	f.Config.execWithEnv(debug, CalleeFirewall, f.Config.FirewallCommandId, noExec, "GOTRACK_FIREWALL_HASH="+hash, "GOTRACK_FIREWALL_DIFF="+diff)
+	return FirewallExec
+}
+
+// readRuleset dumps and normalizes the ruleset
+func (f *FirewallTracker) readRuleset() ([]string, error) {
+	data, err := f.dump()
+	if err != nil {
+		return nil, err
+	}
+	return normalizeFirewall(data)
+}
+
+// dumpCommand executes FirewallDumpCommand with FirewallDumpArgs
+func (f *FirewallTracker) dumpCommand() ([]byte, error) {
+	return exec.Command(f.Config.FirewallDumpCommand, f.Config.FirewallDumpArgs...).Output()
+}
+
+// normalizeFirewall returns one line per rule. nft JSON output is reduced to its ruleset elements without handles and counter values,
+// text output like iptables-save is stripped of comments and counters.
+func normalizeFirewall(data []byte) ([]string, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, "{") {
+		var lines []string
+		for _, line := range splitFirewallLines(trimmed) {
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			lines = append(lines, strings.Join(strings.Fields(firewallCounters.ReplaceAllString(line, "[0:0]")), " "))
+		}
+		return lines, nil
+	}
+
+	var ruleset struct {
+		Nftables []map[string]interface{} `json:"nftables"`
+	}
+	if err := json.Unmarshal([]byte(trimmed), &ruleset); err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, element := range ruleset.Nftables {
+		if _, ok := element["metainfo"]; ok {
+			continue
+		}
+		stripFirewallKeys(element)
+		// Keys of maps are sorted, so equal elements result in equal lines
+		line, err := json.Marshal(element)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, string(line))
+	}
+	return lines, nil
+}
+
+// stripFirewallKeys removes firewallVolatileKeys from all nested objects
+func stripFirewallKeys(value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for _, key := range firewallVolatileKeys {
+			delete(typed, key)
+		}
+		for _, nested := range typed {
+			stripFirewallKeys(nested)
+		}
+	case []interface{}:
+		for _, nested := range typed {
+			stripFirewallKeys(nested)
+		}
+	}
+}
+
+// splitFirewallLines splits text into its non-empty lines
+func splitFirewallLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// hashFirewallLines returns the sha256 of the normalized ruleset
+func hashFirewallLines(lines []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// diffFirewallLines returns the lines missing in current prefixed with "-" and the new lines prefixed with "+"
+func diffFirewallLines(baseline, current []string) string {
+	inBaseline := make(map[string]bool)
+	for _, line := range baseline {
+		inBaseline[line] = true
+	}
+	inCurrent := make(map[string]bool)
+	for _, line := range current {
+		inCurrent[line] = true
+	}
+	var diff []string
+	for _, line := range baseline {
+		if !inCurrent[line] {
+			diff = append(diff, "- "+line)
+		}
+	}
+	for _, line := range current {
+		if !inBaseline[line] {
+			diff = append(diff, "+ "+line)
+		}
+	}
+	if len(diff) == 0 {
+		// Same rules in a different order
+		return "~ order of rules changed"
+	}
+	return strings.Join(diff, "\n")
+}
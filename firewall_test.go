@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const firewallTestRuleset = `{"nftables": [{"metainfo": {"version": "1.0.9", "json_schema_version": 1}},
+{"table": {"family": "inet", "name": "filter", "handle": 1}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 4, "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": 22}}, {"counter": {"packets": %d, "bytes": 60}}, {"accept": null}]}}%s]}`
+
+func TestFirewallTracker_TrackFirewall(t *testing.T) {
+	config := NewConfig()
+	config.LogFile = ""
+	config.ExecOnError = false
+	config.FirewallBaselinePath = filepath.Join(t.TempDir(), "firewall.baseline")
+	openedPort := `,
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 7, "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": 4444}}, {"accept": null}]}}`
+
+	f := NewFirewallTracker(config)
+	ruleset := firewallTestRuleset
+	packets := 1
+	extra := ""
+	var dumpErr error
+	f.dump = func() ([]byte, error) {
+		packets++
+		return []byte(fmt.Sprintf(ruleset, packets, extra)), dumpErr
+	}
+	if err := f.InitFirewall(false, false); err != nil {
+		t.Fatalf("InitFirewall() error = %v", err)
+	}
+	if _, err := os.Stat(config.FirewallBaselinePath); err != nil {
+		t.Fatalf("Baseline not stored: %v", err)
+	}
+
+	steps := []struct {
+		name   string
+		action func()
+		want   uint8
+	}{
+		{name: "Counters changed", action: func() {}, want: FirewallOk},
+		{name: "Port opened", action: func() { extra = openedPort }, want: FirewallExec},
+		{name: "Still opened", action: func() {}, want: FirewallDrift},
+		{name: "Restored", action: func() { extra = "" }, want: FirewallOk},
+		{name: "Dump failed", action: func() { dumpErr = errors.New("nft missing") }, want: FirewallErr},
+	}
+	for _, step := range steps {
+		step.action()
+		if got := f.TrackFirewall(true, false); got != step.want {
+			t.Errorf("%s: TrackFirewall() = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	// Without a baseline nothing is reported until the initialization succeeds
+	storedBaseline := config.FirewallBaselinePath
+	config.FirewallBaselinePath = filepath.Join(t.TempDir(), "retry", "firewall.baseline")
+	config.ExecOnError = true
+	dumpErr = errors.New("nft not ready")
+	extra = ""
+	f = NewFirewallTracker(config)
+	f.dump = func() ([]byte, error) {
+		return []byte(fmt.Sprintf(ruleset, packets, extra)), dumpErr
+	}
+	if err := f.InitFirewall(false, false); err == nil {
+		t.Fatal("InitFirewall() error = nil, want error")
+	}
+	if got := f.TrackFirewall(true, false); got != FirewallNoBaseline {
+		t.Errorf("Not ready: TrackFirewall() = %v, want %v", got, FirewallNoBaseline)
+	}
+	dumpErr = nil
+	ruleset = `{"nftables": [{"metainfo": {"version": "1.0.9", "json_schema_version": %d}}%s]}`
+	if got := f.TrackFirewall(true, false); got != FirewallNoBaseline {
+		t.Errorf("Empty ruleset: TrackFirewall() = %v, want %v", got, FirewallNoBaseline)
+	}
+	if _, err := os.Stat(config.FirewallBaselinePath); !os.IsNotExist(err) {
+		t.Errorf("Empty ruleset stored as baseline: %v", err)
+	}
+	ruleset = firewallTestRuleset
+	if got := f.TrackFirewall(true, false); got != FirewallOk {
+		t.Errorf("Ready: TrackFirewall() = %v, want %v", got, FirewallOk)
+	}
+	extra = openedPort
+	if got := f.TrackFirewall(true, false); got != FirewallExec {
+		t.Errorf("Port opened after retry: TrackFirewall() = %v, want %v", got, FirewallExec)
+	}
+	config.FirewallBaselinePath = storedBaseline
+	config.ExecOnError = false
+
+	// A stored baseline is loaded instead of the current ruleset
+	dumpErr = nil
+	extra = openedPort
+	f = NewFirewallTracker(config)
+	f.dump = func() ([]byte, error) {
+		return []byte(fmt.Sprintf(ruleset, packets, extra)), nil
+	}
+	if err := f.InitFirewall(false, false); err != nil {
+		t.Fatalf("InitFirewall() error = %v", err)
+	}
+	if got := f.TrackFirewall(true, false); got != FirewallExec {
+		t.Errorf("Stored baseline: TrackFirewall() = %v, want %v", got, FirewallExec)
+	}
+}
+
+func Test_normalizeFirewall(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "nft JSON",
+			data: `{"nftables": [{"metainfo": {"version": "1.0.9"}}, {"table": {"family": "inet", "name": "filter", "handle": 1}}, {"rule": {"chain": "input", "handle": 4, "expr": [{"counter": {"packets": 5, "bytes": 300}}]}}]}`,
+			want: []string{`{"table":{"family":"inet","name":"filter"}}`, `{"rule":{"chain":"input","expr":[{"counter":{}}]}}`},
+		},
+		{
+			name: "iptables-save",
+			data: "# Generated by iptables-save\n*filter\n:INPUT DROP [12:3456]\n-A INPUT  -p tcp --dport 22 -j ACCEPT\nCOMMIT\n# Completed\n",
+			want: []string{"*filter", ":INPUT DROP [0:0]", "-A INPUT -p tcp --dport 22 -j ACCEPT", "COMMIT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeFirewall([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("normalizeFirewall() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeFirewall() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_diffFirewallLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline []string
+		current  []string
+		want     string
+	}{
+		{name: "Added and removed", baseline: []string{"a", "b"}, current: []string{"a", "c"}, want: "- b\n+ c"},
+		{name: "Reordered", baseline: []string{"a", "b"}, current: []string{"b", "a"}, want: "~ order of rules changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffFirewallLines(tt.baseline, tt.current); got != tt.want {
+				t.Errorf("diffFirewallLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// fileFollower follows a file like "tail -F". Rotation and truncation of the file are detected on each read.
+type fileFollower struct {
+	path    string
+	file    *os.File
+	offset  int64
+	partial string
+	// atEnd is true until the file has been opened the first time. Content present at start is skipped.
+	atEnd bool
+}
+
+// newFileFollower creates a fileFollower for path. If path exists its current content is skipped, a file created later is read from start.
+func newFileFollower(path string) *fileFollower {
+	f := &fileFollower{path: path, atEnd: true}
+	if err := f.open(); err != nil {
+		f.atEnd = false
+	}
+	return f
+}
+
+// readLines returns all complete lines appended since the last call
+func (f *fileFollower) readLines() ([]string, error) {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := f.readNew()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for rotation: the path now points to another file. The old file has been read completely above.
+	if pathInfo, err := os.Stat(f.path); err == nil {
+		if fileInfo, err := f.file.Stat(); err == nil && !os.SameFile(pathInfo, fileInfo) {
+			f.close()
+			if err := f.open(); err == nil {
+				rotated, err := f.readNew()
+				if err != nil {
+					return nil, err
+				}
+				data += rotated
+			}
+		}
+	}
+	return f.split(data), nil
+}
+
+// open opens the file at path. It is read from start unless this is the first open of a followed file.
+func (f *fileFollower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	f.offset = 0
+	if f.atEnd {
+		f.offset, err = file.Seek(0, io.SeekEnd)
+		if err != nil {
+			f.close()
+			return err
+		}
+	}
+	f.atEnd = false
+	return nil
+}
+
+// readNew reads all content appended since the last read and handles truncation of the file
+func (f *fileFollower) readNew() (string, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Size() < f.offset {
+		// File was truncated -> start over
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		f.offset = 0
+		f.partial = ""
+	}
+	data, err := io.ReadAll(f.file)
+	f.offset += int64(len(data))
+	return string(data), err
+}
+
+// split splits data into lines. An incomplete last line is kept for the next call.
+func (f *fileFollower) split(data string) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.Split(f.partial+data, "\n")
+	f.partial = lines[len(lines)-1]
+	return lines[:len(lines)-1]
+}
+
+// close closes the followed file
+func (f *fileFollower) close() {
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
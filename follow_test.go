@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// appendToFile appends content to the file at path
+func appendToFile(t *testing.T, path, content string) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("Error opening file: %v", err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatalf("Error writing file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Error closing file: %v", err)
+	}
+}
+
+func Test_fileFollower_readLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	appendToFile(t, path, "old line\n")
+
+	f := newFileFollower(path)
+	defer f.close()
+
+	steps := []struct {
+		name   string
+		action func()
+		want   []string
+	}{
+		{name: "Content present at start is skipped", action: func() {}, want: nil},
+		{name: "Appended lines", action: func() { appendToFile(t, path, "first\nsecond\n") }, want: []string{"first", "second"}},
+		{name: "Incomplete line is kept", action: func() { appendToFile(t, path, "thi") }, want: nil},
+		{name: "Completed line", action: func() { appendToFile(t, path, "rd\n") }, want: []string{"third"}},
+		{
+			name: "Rotation",
+			action: func() {
+				appendToFile(t, path, "before rotation\n")
+				if err := os.Rename(path, path+".1"); err != nil {
+					t.Fatalf("Error rotating file: %v", err)
+				}
+				appendToFile(t, path, "after rotation\n")
+			},
+			want: []string{"before rotation", "after rotation"},
+		},
+		{
+			name: "Truncation",
+			action: func() {
+				if err := os.Truncate(path, 0); err != nil {
+					t.Fatalf("Error truncating file: %v", err)
+				}
+				appendToFile(t, path, "new\n")
+			},
+			want: []string{"new"},
+		},
+	}
+	for _, step := range steps {
+		step.action()
+		got, err := f.readLines()
+		if err != nil {
+			t.Fatalf("%s: readLines() error = %v", step.name, err)
+		}
+		if (len(got) > 0 || len(step.want) > 0) && !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: readLines() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func Test_fileFollower_createdLater(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "later.log")
+	f := newFileFollower(path)
+	defer f.close()
+
+	if _, err := f.readLines(); err == nil {
+		t.Errorf("readLines() on missing file, want error")
+	}
+	appendToFile(t, path, "created\n")
+	got, err := f.readLines()
+	if err != nil {
+		t.Fatalf("readLines() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"created"}) {
+		t.Errorf("readLines() = %v, want file read from start", got)
+	}
+}
@@ -6,12 +6,13 @@ require (
 	github.com/hashicorp/go-version v1.7.0
 	github.com/prometheus-community/pro-bing v0.7.0
 	github.com/spf13/pflag v1.0.10
+	golang.org/x/crypto v0.43.0
+	golang.org/x/net v0.46.0
+	golang.org/x/sys v0.37.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
 	github.com/google/uuid v1.6.0 // indirect
-	golang.org/x/net v0.46.0 // indirect
 	golang.org/x/sync v0.17.0 // indirect
-	golang.org/x/sys v0.37.0 // indirect
 )
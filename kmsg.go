@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// kmsgFacilities maps syslog facility names to their numbers
+var kmsgFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// kmsgPriorities maps syslog priority names to their numbers
+var kmsgPriorities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3, "warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// kmsgRecord represents a record of the kernel ring buffer
+type kmsgRecord struct {
+	facility  int
+	priority  int
+	sequence  uint64
+	timestamp time.Duration
+	message   string
+}
+
+// kmsgRule represents a KmsgTarget with resolved facility, priority and pattern
+type kmsgRule struct {
+	target   KmsgTarget
+	facility int
+	priority int
+	pattern  *regexp.Regexp
+}
+
+// KmsgTracker represents the kernel message tracking service
+type KmsgTracker struct {
+	Config *Config
+	rules  []kmsgRule
+	// open opens the kernel messages at a path
+	open func(path string, skipExisting bool) (io.ReadCloser, error)
+}
+
+// NewKmsgTracker creates a new KmsgTracker instance. Targets with unknown facility, priority or invalid patterns are ignored,
+// NewConfigFromFile already rejects them.
+func NewKmsgTracker(config *Config) *KmsgTracker {
+	k := &KmsgTracker{Config: config, open: openKmsg}
+	for _, target := range config.KmsgTrackingConfigs {
+		rule, err := newKmsgRule(target)
+		if err != nil {
+			config.logErr(err)
+			continue
+		}
+		k.rules = append(k.rules, rule)
+	}
+	return k
+}
+
+// newKmsgRule resolves facility, priority and pattern of the target
+func newKmsgRule(target KmsgTarget) (kmsgRule, error) {
+	rule := kmsgRule{target: target, facility: -1, priority: -1}
+	if len(target.Facility) > 0 {
+		facility, ok := kmsgFacilities[target.Facility]
+		if !ok {
+			return rule, errors.New("unknown kmsg facility: " + target.Facility)
+		}
+		rule.facility = facility
+	}
+	if len(target.Priority) > 0 {
+		priority, ok := kmsgPriorities[target.Priority]
+		if !ok {
+			return rule, errors.New("unknown kmsg priority: " + target.Priority)
+		}
+		rule.priority = priority
+	}
+	if len(target.Pattern) > 0 {
+		pattern, err := regexp.Compile(target.Pattern)
+		if err != nil {
+			return rule, errors.New("invalid kmsg pattern " + target.Pattern + ": " + err.Error())
+		}
+		rule.pattern = pattern
+	}
+	return rule, nil
+}
+
+// kmsgReopenDelay is the time to wait before reopening the kernel messages after a read error
+var kmsgReopenDelay = 5 * time.Second
+
+// TrackKmsg reads kernel messages in streaming mode and blocks until the stream ends. Meant to be executed async.
+// Messages present at start are skipped for /dev/kmsg, other files like recorded streams are read from start.
+// On read errors the messages are reopened and records already seen are skipped. Returns the number of matching records.
+func (k *KmsgTracker) TrackKmsg(noExec, debug bool) uint {
+	reader, err := k.open(k.Config.KmsgPath, true)
+	if err != nil {
+		k.Config.logErr(err)
+		if k.Config.ExecOnError {
+			k.Config.exec(debug, CalleeKmsg, -1, noExec)
+		}
+		return 0
+	}
+
+	var counter uint = 0
+	var last int64 = -1
+	for {
+		matches, err := k.stream(noExec, debug, reader, &last)
+		counter += matches
+		_ = reader.Close()
+		if err == nil {
+			return counter
+		}
+		k.Config.logErr(err)
+		if k.Config.ExecOnError {
+			k.Config.exec(debug, CalleeKmsg, -1, noExec)
+		}
+		// Reopen from the start of the ring buffer, the sequence numbers skip records already handled.
+		// Without any record seen the existing messages are skipped like at start.
+		for {
+			time.Sleep(kmsgReopenDelay)
+			if reader, err = k.open(k.Config.KmsgPath, last < 0); err == nil {
+				break
+			}
+			k.Config.logErr(err)
+		}
+		k.Config.log("Reopened kernel messages after read error")
+	}
+}
+
+// stream handles all records of reader with a sequence number above last. Returns nil at the end of the stream or the read error.
+func (k *KmsgTracker) stream(noExec, debug bool, reader io.Reader, last *int64) (uint, error) {
+	// Each read on /dev/kmsg returns one record, so the buffer must fit the largest record
+	buffered := bufio.NewReaderSize(reader, 16384)
+	var counter uint = 0
+	for {
+		line, err := buffered.ReadString('\n')
+		if len(line) > 0 {
+			if record, ok := parseKmsgRecord(line); ok && int64(record.sequence) > *last {
+				*last = int64(record.sequence)
+				counter += k.handleRecord(noExec, debug, record)
+			}
+		}
+		if errors.Is(err, syscall.EPIPE) {
+			// Records were overwritten before being read
+			k.Config.log("Kernel messages lost as ring buffer was overrun")
+			continue
+		} else if errors.Is(err, io.EOF) {
+			return counter, nil
+		} else if err != nil {
+			return counter, err
+		}
+	}
+}
+
+// openKmsg opens the kernel messages at path. If skipExisting is set, records present in the ring buffer of /dev/kmsg are skipped.
+func openKmsg(path string, skipExisting bool) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := file.Stat(); skipExisting && err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		if _, err := file.Seek(0, io.SeekEnd); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// handleRecord executes the commands of all rules matching the record. Returns the number of matching rules.
+func (k *KmsgTracker) handleRecord(noExec, debug bool, record kmsgRecord) uint {
+	var counter uint = 0
+	for _, rule := range k.rules {
+		if !rule.matches(record) {
+			continue
+		}
+		counter++
+		k.Config.log("Executing on kernel message: " + record.message)
+		k.Config.execWithEnv(debug, CalleeKmsg, rule.target.CommandId, noExec,
+			"GOTRACK_KMSG_FACILITY="+strconv.Itoa(record.facility),
+			"GOTRACK_KMSG_PRIORITY="+strconv.Itoa(record.priority),
+			"GOTRACK_KMSG_MESSAGE="+record.message,
+		)
+	}
+	if debug && counter == 0 {
+		k.Config.log("Kernel message not matching: " + record.message)
+	}
+	return counter
+}
+
+// matches checks if a record fulfills all conditions of the rule
+func (r kmsgRule) matches(record kmsgRecord) bool {
+	if r.facility >= 0 && record.facility != r.facility {
+		return false
+	}
+	// Lower values are more severe
+	if r.priority >= 0 && record.priority > r.priority {
+		return false
+	}
+	if r.pattern != nil && !r.pattern.MatchString(record.message) {
+		return false
+	}
+	return true
+}
+
+// parseKmsgRecord parses a line in the format "priority,sequence,timestamp,flags;message". Continuation lines are not records.
+func parseKmsgRecord(line string) (kmsgRecord, bool) {
+	header, message, found := strings.Cut(strings.TrimRight(line, "\n"), ";")
+	if !found || strings.HasPrefix(line, " ") {
+		return kmsgRecord{}, false
+	}
+	fields := strings.Split(header, ",")
+	if len(fields) < 3 {
+		return kmsgRecord{}, false
+	}
+	prefix, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return kmsgRecord{}, false
+	}
+	sequence, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return kmsgRecord{}, false
+	}
+	timestamp, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return kmsgRecord{}, false
+	}
+	return kmsgRecord{
+		facility:  prefix >> 3,
+		priority:  prefix & 7,
+		sequence:  sequence,
+		timestamp: time.Duration(timestamp) * time.Microsecond,
+		message:   message,
+	}, true
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedKmsg is a recorded kmsg stream including a continuation line
+const recordedKmsg = `6,1001,5140900,-;usb 1-1: new high-speed USB device number 5 using xhci_hcd
+6,1002,5141000,-;usb-storage 1-1:1.0: USB Mass Storage device detected
+ SUBSYSTEM=usb
+ DEVICE=c189:4
+3,1003,5142000,-;thunderbolt 0-1: device authorization failed
+30,1004,5143000,-;systemd[1]: Started Session 3 of User alice.
+`
+
+func Test_parseKmsgRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want kmsgRecord
+		ok   bool
+	}{
+		{
+			name: "Kernel record",
+			line: "6,1002,5141000,-;usb-storage 1-1:1.0: USB Mass Storage device detected\n",
+			want: kmsgRecord{facility: 0, priority: 6, sequence: 1002, timestamp: 5141 * time.Millisecond, message: "usb-storage 1-1:1.0: USB Mass Storage device detected"},
+			ok:   true,
+		},
+		{
+			name: "Daemon record",
+			line: "30,1004,5143000,-;systemd[1]: Started",
+			want: kmsgRecord{facility: 3, priority: 6, sequence: 1004, timestamp: 5143 * time.Millisecond, message: "systemd[1]: Started"},
+			ok:   true,
+		},
+		{name: "Continuation line", line: " SUBSYSTEM=usb\n", ok: false},
+		{name: "Invalid header", line: "a,b,c;message\n", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseKmsgRecord(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseKmsgRecord() ok = %v, want %v", ok, tt.ok)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKmsgRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKmsgTracker_TrackKmsg(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kmsg")
+	if err := os.WriteFile(path, []byte(recordedKmsg), 0600); err != nil {
+		t.Fatalf("Error writing recorded kmsg: %v", err)
+	}
+	tests := []struct {
+		name    string
+		targets []KmsgTarget
+		want    uint
+	}{
+		{name: "Pattern", targets: []KmsgTarget{{Pattern: "usb-storage"}}, want: 1},
+		{name: "Facility", targets: []KmsgTarget{{Facility: "kern"}}, want: 3},
+		{name: "Priority", targets: []KmsgTarget{{Priority: "err"}}, want: 1},
+		{name: "Facility and pattern", targets: []KmsgTarget{{Facility: "daemon", Pattern: "Session"}}, want: 1},
+		{name: "Multiple rules", targets: []KmsgTarget{{Pattern: "usb"}, {Pattern: "thunderbolt"}}, want: 3},
+		{name: "Unknown facility is ignored", targets: []KmsgTarget{{Facility: "unknown"}}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewConfig()
+			config.LogFile = ""
+			config.KmsgPath = path
+			config.KmsgTrackingConfigs = tt.targets
+			if got := NewKmsgTracker(config).TrackKmsg(true, false); got != tt.want {
+				t.Errorf("TrackKmsg() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// failingReader returns its content followed by err
+type failingReader struct {
+	io.Reader
+	err error
+}
+
+func (r failingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, r.err
+	}
+	return n, err
+}
+
+func (r failingReader) Close() error {
+	return nil
+}
+
+func TestKmsgTracker_TrackKmsg_reopen(t *testing.T) {
+	reopenDelay := kmsgReopenDelay
+	kmsgReopenDelay = time.Millisecond
+	defer func() {
+		kmsgReopenDelay = reopenDelay
+	}()
+	marker := filepath.Join(t.TempDir(), "executed")
+	config := NewConfig()
+	config.LogFile = ""
+	config.ExecOnError = true
+	config.Commands = []Command{{Command: "touch", Args: []string{marker}, Kmsg: false, Id: -1}}
+	config.KmsgTrackingConfigs = []KmsgTarget{{Pattern: "usb"}}
+
+	lines := strings.SplitAfter(recordedKmsg, "\n")
+	// The first stream fails after the first record, the reopened stream starts at the beginning of the ring buffer
+	streams := []io.ReadCloser{
+		failingReader{Reader: strings.NewReader(lines[0]), err: errors.New("read failed")},
+		io.NopCloser(strings.NewReader(recordedKmsg)),
+	}
+	var opened []bool
+	k := NewKmsgTracker(config)
+	k.open = func(path string, skipExisting bool) (io.ReadCloser, error) {
+		opened = append(opened, skipExisting)
+		if len(opened) == 2 {
+			return nil, errors.New("not ready")
+		}
+		stream := streams[0]
+		streams = streams[1:]
+		return stream, nil
+	}
+
+	// Each usb record is handled once
+	if got := k.TrackKmsg(true, false); got != 2 {
+		t.Errorf("TrackKmsg() = %v, want 2", got)
+	}
+	if want := []bool{true, false, false}; !reflect.DeepEqual(opened, want) {
+		t.Errorf("TrackKmsg() opened with skipExisting %v, want %v", opened, want)
+	}
+
+	// Commands are executed on read errors. Without a record seen the existing messages are skipped on reopen too.
+	config.Commands[0].Kmsg = true
+	streams = []io.ReadCloser{failingReader{Reader: strings.NewReader(""), err: errors.New("read failed")}, io.NopCloser(strings.NewReader(""))}
+	opened = nil
+	k.TrackKmsg(false, false)
+	if !fileExists(marker) {
+		t.Error("TrackKmsg() did not execute on read error")
+	}
+	if want := []bool{true, true, true}; !reflect.DeepEqual(opened, want) {
+		t.Errorf("TrackKmsg() without record opened with skipExisting %v, want %v", opened, want)
+	}
+}
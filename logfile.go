@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// logRule represents a LogTarget with its compiled pattern
+type logRule struct {
+	target  LogTarget
+	pattern *regexp.Regexp
+	window  *slidingWindow
+}
+
+// LogTracker represents the log file tracking service
+type LogTracker struct {
+	Config    *Config
+	followers map[string]*fileFollower
+	rules     []*logRule
+}
+
+// NewLogTracker creates a new LogTracker instance. Existing log content is skipped. Targets with invalid patterns are ignored,
+// configs loaded from file reject them.
+func NewLogTracker(config *Config) *LogTracker {
+	l := &LogTracker{
+		Config:    config,
+		followers: make(map[string]*fileFollower),
+	}
+	for _, target := range config.LogTrackingConfigs {
+		pattern, err := regexp.Compile(target.Pattern)
+		if err != nil {
+			config.log("Invalid pattern for log file: " + target.Path)
+			config.logErr(err)
+			continue
+		}
+		l.rules = append(l.rules, &logRule{target: target, pattern: pattern, window: &slidingWindow{window: target.Window}})
+		// Targets on the same file share one follower
+		if _, ok := l.followers[target.Path]; !ok {
+			l.followers[target.Path] = newFileFollower(target.Path)
+		}
+	}
+	return l
+}
+
+// TrackLogFiles reads new lines of all followed files and matches them. Meant to be executed periodically. Returns the number of matches.
+func (l *LogTracker) TrackLogFiles(noExec, debug bool) uint {
+	var counter uint = 0
+	for path, follower := range l.followers {
+		lines, err := follower.readLines()
+		if os.IsNotExist(err) {
+			// Wait for the file to appear, it is read from start then
+			if debug {
+				l.Config.logErr(err)
+			}
+			continue
+		}
+		if err != nil {
+			l.Config.logErr(err)
+			if l.Config.ExecOnError {
+				l.Config.exec(debug, CalleeLog, -1, noExec)
+			}
+			continue
+		}
+		for _, line := range lines {
+			counter += l.matchLine(noExec, debug, path, line, time.Now())
+		}
+	}
+	return counter
+}
+
+// matchLine checks a line of path against all rules for path. Returns the number of matching rules.
+func (l *LogTracker) matchLine(noExec, debug bool, path, line string, now time.Time) uint {
+	var counter uint = 0
+	for _, rule := range l.rules {
+		if rule.target.Path != path || !rule.pattern.MatchString(line) {
+			continue
+		}
+		counter++
+		if debug {
+			l.Config.log("Log line matched in " + path + ": " + line)
+		}
+		count := rule.window.add(now)
+		if count >= rule.target.Count {
+			l.Config.log("Executing on log tracking for: " + path + " Line: " + line)
+			rule.window.reset()
+			l.Config.execWithEnv(debug, CalleeLog, rule.target.CommandId, noExec,
+				"GOTRACK_LOG_PATH="+path,
+				"GOTRACK_LOG_LINE="+line,
+				"GOTRACK_LOG_COUNT="+strconv.Itoa(count),
+			)
+		}
+	}
+	return counter
+}
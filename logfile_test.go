@@ -0,0 +1,88 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewLogTracker(t *testing.T) {
+	dir := t.TempDir()
+	config := NewConfig()
+	config.LogFile = ""
+	config.LogTrackingConfigs = []LogTarget{
+		{Path: filepath.Join(dir, "a.log"), Pattern: "error"},
+		{Path: filepath.Join(dir, "a.log"), Pattern: "warning"},
+		{Path: filepath.Join(dir, "b.log"), Pattern: "("},
+	}
+	l := NewLogTracker(config)
+	if len(l.rules) != 2 {
+		t.Errorf("NewLogTracker() rules = %v, want 2 as invalid patterns are ignored", len(l.rules))
+	}
+	if len(l.followers) != 1 {
+		t.Errorf("NewLogTracker() followers = %v, want 1 shared follower", len(l.followers))
+	}
+}
+
+func TestLogTracker_TrackLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kern.log")
+	config := NewConfig()
+	config.LogFile = ""
+	config.LogTrackingConfigs = []LogTarget{
+		{Path: path, Pattern: "Oops", Count: 1, CommandId: -1},
+		{Path: path, Pattern: `usb \d+-\d+: new`, Count: 3, Window: time.Minute, CommandId: -1},
+	}
+	appendToFile(t, path, "Oops: old\n")
+
+	l := NewLogTracker(config)
+	tests := []struct {
+		name    string
+		content string
+		want    uint
+		counted int
+	}{
+		{name: "Old content", content: "", want: 0, counted: 0},
+		{name: "Single match", content: "kernel: Oops: 0002 [#1]\nkernel: unrelated\n", want: 1, counted: 0},
+		{name: "Counted matches", content: "usb 1-1: new device\nusb 1-2: new device\n", want: 2, counted: 2},
+		{name: "Count reached", content: "usb 1-3: new device\n", want: 1, counted: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.content) > 0 {
+				appendToFile(t, path, tt.content)
+			}
+			if got := l.TrackLogFiles(true, false); got != tt.want {
+				t.Errorf("TrackLogFiles() = %v, want %v", got, tt.want)
+			}
+			if got := len(l.rules[1].window.events); got != tt.counted {
+				t.Errorf("Counted matches = %v, want %v", got, tt.counted)
+			}
+		})
+	}
+}
+
+func TestLogTracker_TrackLogFiles_missingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	marker := filepath.Join(dir, "executed")
+	config := NewConfig()
+	config.LogFile = ""
+	config.ExecOnError = true
+	config.Commands = []Command{{Command: "touch", Args: []string{marker}, Log: true, Id: -1}}
+	config.LogTrackingConfigs = []LogTarget{{Path: path, Pattern: "error", CommandId: -1}}
+
+	l := NewLogTracker(config)
+	if got := l.TrackLogFiles(false, false); got != 0 {
+		t.Errorf("TrackLogFiles() before file exists = %v, want 0", got)
+	}
+	if fileExists(marker) {
+		t.Fatal("TrackLogFiles() executed commands for a missing file")
+	}
+
+	// A file created later is read from start
+	appendToFile(t, path, "error: first\n")
+	if got := l.TrackLogFiles(true, false); got != 1 {
+		t.Errorf("TrackLogFiles() after file appeared = %v, want 1", got)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// utmpUserProcess is the ut_type of a normal login session
+const utmpUserProcess int16 = 7
+
+// utmpRecord represents the binary layout of a utmp/wtmp entry as written by glibc on Linux
+type utmpRecord struct {
+	Type    int16
+	_       [2]byte
+	PID     int32
+	Line    [32]byte
+	ID      [4]byte
+	User    [32]byte
+	Host    [256]byte
+	Exit    [2]int16
+	Session int32
+	Sec     int32
+	Usec    int32
+	Addr    [4]int32
+	_       [20]byte
+}
+
+// LoginSession represents a login session read from utmp or wtmp
+type LoginSession struct {
+	User string
+	TTY  string
+	Host string
+	PID  int
+	Time time.Time
+}
+
+// LoginTracker represents the Login tracking service
+type LoginTracker struct {
+	Config        *Config
+	knownSessions map[string]bool
+	wtmpOffset    int64
+}
+
+// NewLoginTracker creates a new LoginTracker instance
+func NewLoginTracker(config *Config) *LoginTracker {
+	return &LoginTracker{
+		Config: config,
+	}
+}
+
+// InitLoginSessions registers the sessions present at start, so only later logins are reported
+func (l *LoginTracker) InitLoginSessions(verbose, debug bool) {
+	l.knownSessions = make(map[string]bool)
+	sessions, err := readUtmpSessions(l.Config.LoginUtmpPath, 0)
+	if err != nil {
+		l.Config.logErr(err)
+	}
+	for _, session := range sessions.records {
+		l.knownSessions[session.key()] = true
+	}
+	// Skip history already present in wtmp
+	if info, err := os.Stat(l.Config.LoginWtmpPath); err == nil {
+		l.wtmpOffset = info.Size() - info.Size()%int64(binary.Size(utmpRecord{}))
+	}
+	if verbose {
+		fmt.Println("Logged in at start:\nUser\tTTY\tHost\tTime")
+		for _, session := range sessions.records {
+			fmt.Println(session.User + "\t" + session.TTY + "\t" + session.Host + "\t" + session.Time.Format(time.RFC3339))
+		}
+	}
+}
+
+// TrackLoginSessions tracks new login sessions. Meant to be executed periodically
+func (l *LoginTracker) TrackLoginSessions(noExec, debug bool) uint {
+	if l.knownSessions == nil {
+		l.knownSessions = make(map[string]bool)
+	}
+
+	current, errUtmp := readUtmpSessions(l.Config.LoginUtmpPath, 0)
+	history, errWtmp := readUtmpSessions(l.Config.LoginWtmpPath, l.wtmpOffset)
+	if errUtmp != nil && errWtmp != nil {
+		// Neither source is readable
+		l.Config.logErr(errUtmp)
+		l.Config.logErr(errWtmp)
+		if l.Config.ExecOnError {
+			l.Config.exec(debug, CalleeLogin, -1, noExec)
+		}
+		return 0
+	}
+	if errWtmp == nil {
+		l.wtmpOffset = history.offset
+	} else if debug {
+		l.Config.logErr(errWtmp)
+	}
+
+	// Sessions from wtmp are checked as well to catch logins that ended between two checks
+	known := make(map[string]bool)
+	var counter uint = 0
+	for _, session := range append(history.records, current.records...) {
+		key := session.key()
+		if !l.knownSessions[key] && !known[key] {
+			counter++
+			l.Config.log("New login: " + session.String())
+			l.trigger(noExec, debug, session)
+		}
+		known[key] = true
+	}
+	// Only keep sessions that could be reported again
+	if errUtmp == nil {
+		l.knownSessions = make(map[string]bool)
+		for _, session := range current.records {
+			l.knownSessions[session.key()] = true
+		}
+	} else if debug {
+		l.Config.logErr(errUtmp)
+	}
+	if debug && counter == 0 {
+		l.Config.log("No new logins")
+	}
+	return counter
+}
+
+// trigger executes the commands of all rules matching the session
+func (l *LoginTracker) trigger(noExec, debug bool, session LoginSession) {
+	for _, target := range l.Config.LoginTrackingConfigs {
+		if target.matches(session) {
+			l.Config.execWithEnv(debug, CalleeLogin, target.CommandId, noExec, session.env()...)
+		} else if debug {
+			l.Config.log("Login rule not matching for: " + session.String())
+		}
+	}
+}
+
+// matches checks if a session fulfills all conditions of the rule
+func (t LoginTarget) matches(session LoginSession) bool {
+	if len(t.Users) > 0 && !has(t.Users, session.User) {
+		return false
+	}
+	if len(t.AllowedUsers) > 0 && has(t.AllowedUsers, session.User) {
+		return false
+	}
+	if len(t.TTYs) > 0 && !matchesAny(t.TTYs, session.TTY) {
+		return false
+	}
+	if len(t.Hosts) > 0 && !matchesAny(t.Hosts, session.Host) {
+		return false
+	}
+	if t.RemoteOnly && len(session.Host) == 0 {
+		return false
+	}
+	if t.LocalOnly && len(session.Host) > 0 {
+		return false
+	}
+	return true
+}
+
+// key identifies a session independent of the source it was read from
+func (s LoginSession) key() string {
+	return s.TTY + "|" + strconv.Itoa(s.PID) + "|" + strconv.FormatInt(s.Time.UnixNano(), 10)
+}
+
+// env returns the session details as environment for triggered commands
+func (s LoginSession) env() []string {
+	return []string{
+		"GOTRACK_LOGIN_USER=" + s.User,
+		"GOTRACK_LOGIN_TTY=" + s.TTY,
+		"GOTRACK_LOGIN_HOST=" + s.Host,
+		"GOTRACK_LOGIN_PID=" + strconv.Itoa(s.PID),
+		"GOTRACK_LOGIN_TIME=" + s.Time.Format(time.RFC3339),
+	}
+}
+
+// String formats the session for logging
+func (s LoginSession) String() string {
+	str := "User: " + s.User + " TTY: " + s.TTY
+	if len(s.Host) > 0 {
+		str += " Host: " + s.Host
+	}
+	return str + " Time: " + s.Time.Format(time.RFC3339)
+}
+
+// utmpSessions holds the sessions read from a utmp file and the offset after the last complete record
+type utmpSessions struct {
+	records []LoginSession
+	offset  int64
+}
+
+// readUtmpSessions reads all login sessions from a utmp formatted file starting at offset. A file smaller than offset is read from start as it was rotated.
+func readUtmpSessions(path string, offset int64) (utmpSessions, error) {
+	res := utmpSessions{offset: offset}
+	file, err := os.Open(path)
+	if err != nil {
+		return res, err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	info, err := file.Stat()
+	if err != nil {
+		return res, err
+	}
+	if info.Size() < offset {
+		res.offset = 0
+	}
+	if _, err := file.Seek(res.offset, io.SeekStart); err != nil {
+		return res, err
+	}
+
+	recordSize := int64(binary.Size(utmpRecord{}))
+	for {
+		var record utmpRecord
+		err := binary.Read(file, binary.LittleEndian, &record)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// Incomplete records are read again on the next call
+			break
+		} else if err != nil {
+			return res, err
+		}
+		res.offset += recordSize
+		if record.Type == utmpUserProcess {
+			res.records = append(res.records, record.session())
+		}
+	}
+	return res, nil
+}
+
+// session converts the binary record to a LoginSession
+func (r utmpRecord) session() LoginSession {
+	return LoginSession{
+		User: cString(r.User[:]),
+		TTY:  cString(r.Line[:]),
+		Host: cString(r.Host[:]),
+		PID:  int(r.PID),
+		Time: time.Unix(int64(r.Sec), int64(r.Usec)*1000),
+	}
+}
+
+// cString converts a NUL terminated byte array to a string
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// matchesAny checks if name matches any of the glob patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
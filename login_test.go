@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeUtmpFile writes the sessions as utmp records to path
+func writeUtmpFile(t *testing.T, path string, appendRecords bool, sessions ...LoginSession) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendRecords {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		t.Fatalf("Error opening utmp file: %v", err)
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			t.Errorf("Error closing utmp file: %v", err)
+		}
+	}(file)
+	for _, session := range sessions {
+		record := utmpRecord{Type: utmpUserProcess, PID: int32(session.PID), Sec: int32(session.Time.Unix())}
+		copy(record.User[:], session.User)
+		copy(record.Line[:], session.TTY)
+		copy(record.Host[:], session.Host)
+		if err := binary.Write(file, binary.LittleEndian, &record); err != nil {
+			t.Fatalf("Error writing utmp record: %v", err)
+		}
+	}
+}
+
+func TestLoginTarget_matches(t *testing.T) {
+	local := LoginSession{User: "alice", TTY: "tty1", PID: 1}
+	remote := LoginSession{User: "root", TTY: "pts/0", Host: "192.168.1.20", PID: 2}
+	tests := []struct {
+		name    string
+		target  LoginTarget
+		session LoginSession
+		want    bool
+	}{
+		{name: "Empty rule", target: LoginTarget{}, session: local, want: true},
+		{name: "User listed", target: LoginTarget{Users: []string{"alice"}}, session: local, want: true},
+		{name: "User not listed", target: LoginTarget{Users: []string{"alice"}}, session: remote, want: false},
+		{name: "Allowed user", target: LoginTarget{AllowedUsers: []string{"alice"}}, session: local, want: false},
+		{name: "User outside allowlist", target: LoginTarget{AllowedUsers: []string{"alice"}}, session: remote, want: true},
+		{name: "TTY pattern", target: LoginTarget{TTYs: []string{"pts/*"}}, session: remote, want: true},
+		{name: "TTY pattern not matching", target: LoginTarget{TTYs: []string{"pts/*"}}, session: local, want: false},
+		{name: "Host pattern", target: LoginTarget{Hosts: []string{"192.168.1.*"}}, session: remote, want: true},
+		{name: "Remote only, local session", target: LoginTarget{RemoteOnly: true}, session: local, want: false},
+		{name: "Remote only, remote session", target: LoginTarget{RemoteOnly: true}, session: remote, want: true},
+		{name: "Local only, remote session", target: LoginTarget{LocalOnly: true}, session: remote, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.matches(tt.session); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readUtmpSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wtmp")
+	first := LoginSession{User: "alice", TTY: "tty1", PID: 10, Time: time.Unix(1700000000, 0)}
+	second := LoginSession{User: "root", TTY: "pts/3", Host: "10.0.0.1", PID: 11, Time: time.Unix(1700000100, 0)}
+	writeUtmpFile(t, path, false, first, second)
+	recordSize := int64(binary.Size(utmpRecord{}))
+
+	got, err := readUtmpSessions(path, 0)
+	if err != nil {
+		t.Fatalf("readUtmpSessions() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.records, []LoginSession{first, second}) || got.offset != 2*recordSize {
+		t.Errorf("readUtmpSessions() = %v, want both sessions", got)
+	}
+
+	got, err = readUtmpSessions(path, recordSize)
+	if err != nil {
+		t.Fatalf("readUtmpSessions() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.records, []LoginSession{second}) {
+		t.Errorf("readUtmpSessions() from offset = %v, want %v", got.records, second)
+	}
+
+	// Offset behind end of file is handled as rotation
+	got, err = readUtmpSessions(path, 10*recordSize)
+	if err != nil {
+		t.Fatalf("readUtmpSessions() error = %v", err)
+	}
+	if len(got.records) != 2 {
+		t.Errorf("readUtmpSessions() after rotation = %v, want 2 sessions", got.records)
+	}
+
+	if _, err := readUtmpSessions(filepath.Join(t.TempDir(), "missing"), 0); err == nil {
+		t.Errorf("readUtmpSessions() on missing file, want error")
+	}
+}
+
+func TestLoginTracker_TrackLoginSessions(t *testing.T) {
+	dir := t.TempDir()
+	config := NewConfig()
+	config.LogFile = ""
+	config.LoginUtmpPath = filepath.Join(dir, "utmp")
+	config.LoginWtmpPath = filepath.Join(dir, "wtmp")
+
+	existing := LoginSession{User: "alice", TTY: "tty1", PID: 10, Time: time.Unix(1700000000, 0)}
+	writeUtmpFile(t, config.LoginUtmpPath, false, existing)
+	writeUtmpFile(t, config.LoginWtmpPath, false, existing)
+
+	l := NewLoginTracker(config)
+	l.InitLoginSessions(false, false)
+	if got := l.TrackLoginSessions(true, false); got != 0 {
+		t.Errorf("TrackLoginSessions() = %v, want 0 for sessions present at start", got)
+	}
+
+	// A login that already ended is only present in wtmp
+	ended := LoginSession{User: "mallory", TTY: "pts/1", Host: "203.0.113.5", PID: 20, Time: time.Unix(1700000200, 0)}
+	current := LoginSession{User: "bob", TTY: "pts/2", PID: 21, Time: time.Unix(1700000300, 0)}
+	writeUtmpFile(t, config.LoginWtmpPath, true, ended, current)
+	writeUtmpFile(t, config.LoginUtmpPath, false, existing, current)
+	if got := l.TrackLoginSessions(true, false); got != 2 {
+		t.Errorf("TrackLoginSessions() = %v, want 2 new sessions", got)
+	}
+	if got := l.TrackLoginSessions(true, false); got != 0 {
+		t.Errorf("TrackLoginSessions() = %v, want 0 on unchanged state", got)
+	}
+}
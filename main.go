@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/hashicorp/go-version"
@@ -16,7 +18,7 @@ const minConfigVersion = "1.8"
 
 func main() {
 	// Define command-line flags
-	intervalFlag := pflag.DurationP("interval", "i", time.Duration(0), "Interval for tracking")
+	intervalFlag := pflag.DurationP("interval", "i", time.Duration(0), "Interval for tracking, targets with an own interval are not affected")
 	debugFlag := pflag.BoolP("debug", "d", false, "Debug mode: Print debugging notes")
 	verboseFlag := pflag.BoolP("verbose", "x", false, "Print state at start")
 	noExecFlag := pflag.BoolP("noExec", "n", false, "Do not execute on device detection")
@@ -53,6 +55,35 @@ func main() {
 		configFilePath = defaultConfigPath
 	}
 
+	// Record a check-in for the dead man's switch of a running instance
+	if pflag.Arg(0) == "checkin" {
+		config := NewConfig()
+		if len(configFilePath) != 0 {
+			var err error
+			if config, err = NewConfigFromFile(configFilePath); err != nil {
+				NewConfig().logErr(err)
+				os.Exit(1)
+			}
+		}
+		if err := Checkin(config); err != nil {
+			fmt.Println("Check-in failed: " + err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("Check-in recorded")
+		return
+	}
+
+	// Print the hash of a passphrase for checkin_passphrase_hash
+	if pflag.Arg(0) == "checkin-hash" {
+		hash, err := HashPassphrase()
+		if err != nil {
+			fmt.Println("Hashing failed: " + err.Error())
+			os.Exit(1)
+		}
+		fmt.Println(hash)
+		return
+	}
+
 	var config *Config
 	var err error
 	debug := *debugFlag
@@ -113,11 +144,25 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Override interval with command-line flag if provided
+	// Override interval with command-line flag if provided. Ping and web targets with an own interval keep it
 	if *intervalFlag != 0 {
 		config.USBInterval = *intervalFlag
 		config.PingInterval = *intervalFlag
 		config.WebInterval = *intervalFlag
+		config.LoginInterval = *intervalFlag
+		config.AuthInterval = *intervalFlag
+		config.LogInterval = *intervalFlag
+		config.ModuleInterval = *intervalFlag
+		config.SysctlInterval = *intervalFlag
+		config.ClockInterval = *intervalFlag
+		config.DisplayInterval = *intervalFlag
+		config.BluetoothInterval = *intervalFlag
+		config.AccountInterval = *intervalFlag
+		config.UnitInterval = *intervalFlag
+		config.FirewallInterval = *intervalFlag
+		config.DNSInterval = *intervalFlag
+		config.PublicIPInterval = *intervalFlag
+		config.CheckinInterval = *intervalFlag
 	}
 
 	// Overwrite command with command-line flag if provided
@@ -160,6 +205,18 @@ func main() {
 
 	config.printAndLog("Waiting for " + config.StartDelay.String() + " at: " + time.Now().Format("15:04:05.00")) // hh:mm:ss,ss
 
+	// Check boot integrity before the delay ends, so commands can react before any tracking starts
+	if config.BootCheck {
+		NewBootChecker(config).CheckBoot(noExec, debug)
+	}
+
+	// Check for unexpected reboots since the last run
+	var rebootTracker *RebootTracker
+	if config.RebootTracking {
+		rebootTracker = NewRebootTracker(config)
+		rebootTracker.CheckReboot(noExec, debug)
+	}
+
 	// Delay execution before start
 	time.Sleep(config.StartDelay)
 	config.printAndLog("Finished waiting at: " + time.Now().Format("15:04:05.00"))
@@ -186,11 +243,12 @@ func main() {
 		}()
 	}
 
+	var pingTracker *PingTracker
 	if config.PingTracking {
-		pingTracker := NewPingTracker(config)
+		pingTracker = NewPingTracker(config)
 
 		// Start ticker
-		pingTicker := time.NewTicker(config.PingInterval)
+		pingTicker := time.NewTicker(pingTracker.TickInterval())
 		defer pingTicker.Stop()
 
 		config.printAndLog("Started Ping tracking at: " + time.Now().Format("15:04:05.00"))
@@ -209,7 +267,7 @@ func main() {
 		webTracker := NewWebTracker(config)
 
 		// Start ticker
-		webTicker := time.NewTicker(config.WebInterval)
+		webTicker := time.NewTicker(webTracker.TickInterval())
 		defer webTicker.Stop()
 
 		config.printAndLog("Started Web tracking at: " + time.Now().Format("15:04:05.00"))
@@ -224,6 +282,308 @@ func main() {
 		}()
 	}
 
+	if config.LoginTracking {
+		loginTracker := NewLoginTracker(config)
+		loginTracker.InitLoginSessions(verbose, debug)
+
+		// Start ticker
+		loginTicker := time.NewTicker(config.LoginInterval)
+		defer loginTicker.Stop()
+
+		config.printAndLog("Started Login tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-loginTicker.C:
+					loginTracker.TrackLoginSessions(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.AuthTracking {
+		authTracker := NewAuthTracker(config)
+
+		// Start ticker
+		authTicker := time.NewTicker(config.AuthInterval)
+		defer authTicker.Stop()
+
+		config.printAndLog("Started Auth tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-authTicker.C:
+					authTracker.TrackAuthFailures(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.LogTracking {
+		logTracker := NewLogTracker(config)
+
+		// Start ticker
+		logTicker := time.NewTicker(config.LogInterval)
+		defer logTicker.Stop()
+
+		config.printAndLog("Started Log tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-logTicker.C:
+					logTracker.TrackLogFiles(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.KmsgTracking {
+		kmsgTracker := NewKmsgTracker(config)
+
+		config.printAndLog("Started Kmsg tracking at: " + time.Now().Format("15:04:05.00"))
+
+		// Reading is blocking until new messages arrive
+		go kmsgTracker.TrackKmsg(noExec, debug)
+	}
+
+	if config.ModuleTracking {
+		moduleTracker := NewModuleTracker(config)
+		moduleTracker.InitModules(verbose, debug)
+
+		// Start ticker
+		moduleTicker := time.NewTicker(config.ModuleInterval)
+		defer moduleTicker.Stop()
+
+		config.printAndLog("Started Module tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-moduleTicker.C:
+					moduleTracker.TrackModules(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.SysctlTracking {
+		sysctlTracker := NewSysctlTracker(config)
+
+		// Start ticker
+		sysctlTicker := time.NewTicker(config.SysctlInterval)
+		defer sysctlTicker.Stop()
+
+		config.printAndLog("Started Sysctl tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-sysctlTicker.C:
+					sysctlTracker.TrackSysctls(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.ClockTracking {
+		clockTracker := NewClockTracker(config)
+		clockTracker.TrackClocks(noExec, debug)
+
+		// Start ticker
+		clockTicker := time.NewTicker(config.ClockInterval)
+		defer clockTicker.Stop()
+
+		config.printAndLog("Started Clock tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-clockTicker.C:
+					clockTracker.TrackClocks(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.DisplayTracking {
+		displayTracker := NewDisplayTracker(config)
+		displayTracker.InitDisplays(verbose, debug)
+
+		// Start ticker
+		displayTicker := time.NewTicker(config.DisplayInterval)
+		defer displayTicker.Stop()
+
+		config.printAndLog("Started Display tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-displayTicker.C:
+					displayTracker.TrackDisplays(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.BluetoothTracking {
+		bluetoothTracker := NewBluetoothTracker(config)
+		bluetoothTracker.InitBluetooth(verbose, debug)
+
+		// Start ticker
+		bluetoothTicker := time.NewTicker(config.BluetoothInterval)
+		defer bluetoothTicker.Stop()
+
+		config.printAndLog("Started Bluetooth tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-bluetoothTicker.C:
+					bluetoothTracker.TrackBluetooth(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.DevTracking {
+		devTracker := NewDevTracker(config)
+		if err := devTracker.InitDevices(debug); err != nil {
+			config.logErr(err)
+		}
+
+		config.printAndLog("Started device node tracking at: " + time.Now().Format("15:04:05.00"))
+
+		// Reading is blocking until new events arrive
+		go devTracker.TrackDevices(noExec, debug)
+	}
+
+	if config.AccountTracking {
+		accountTracker := NewAccountTracker(config)
+		accountTracker.InitAccounts(verbose, debug)
+
+		// Start ticker
+		accountTicker := time.NewTicker(config.AccountInterval)
+		defer accountTicker.Stop()
+
+		config.printAndLog("Started Account tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-accountTicker.C:
+					accountTracker.TrackAccounts(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.UnitTracking {
+		unitTracker := NewUnitTracker(config)
+
+		// Start ticker
+		unitTicker := time.NewTicker(config.UnitInterval)
+		defer unitTicker.Stop()
+
+		config.printAndLog("Started Unit tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-unitTicker.C:
+					unitTracker.TrackUnits(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.FirewallTracking {
+		firewallTracker := NewFirewallTracker(config)
+		if err := firewallTracker.InitFirewall(verbose, debug); err != nil {
+			// TrackFirewall retries until a baseline exists
+			config.logErr(err)
+		}
+
+		// Start ticker
+		firewallTicker := time.NewTicker(config.FirewallInterval)
+		defer firewallTicker.Stop()
+
+		config.printAndLog("Started Firewall tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-firewallTicker.C:
+					firewallTracker.TrackFirewall(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.DNSTracking {
+		dnsTracker := NewDNSTracker(config)
+
+		// Start ticker
+		dnsTicker := time.NewTicker(config.DNSInterval)
+		defer dnsTicker.Stop()
+
+		config.printAndLog("Started DNS tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			for {
+				select {
+				case <-dnsTicker.C:
+					go dnsTracker.TrackDNSTargets(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.PublicIPTracking {
+		publicIPTracker := NewPublicIPTracker(config)
+
+		// Start ticker
+		publicIPTicker := time.NewTicker(config.PublicIPInterval)
+		defer publicIPTicker.Stop()
+
+		config.printAndLog("Started public IP tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			// Learn the current address right away, so a change is detected on the first tick
+			publicIPTracker.TrackPublicIPTargets(noExec, debug)
+			for {
+				select {
+				case <-publicIPTicker.C:
+					go publicIPTracker.TrackPublicIPTargets(noExec, debug)
+				}
+			}
+		}()
+	}
+
+	if config.CheckinTracking {
+		checkinTracker := NewCheckinTracker(config)
+		checkinTracker.InitCheckin(verbose, debug)
+
+		// Start ticker
+		checkinTicker := time.NewTicker(config.CheckinInterval)
+		defer checkinTicker.Stop()
+
+		config.printAndLog("Started check-in tracking at: " + time.Now().Format("15:04:05.00"))
+
+		go func() {
+			// The deadline may have passed while goTrack was not running
+			checkinTracker.TrackCheckin(noExec, debug)
+			for {
+				select {
+				case <-checkinTicker.C:
+					checkinTracker.TrackCheckin(noExec, debug)
+				}
+			}
+		}()
+	}
+
 	if config.TimeTracking {
 		for _, t := range config.TimeTrackingConfigs {
 			until := t.Timestamp.Sub(time.Now())
@@ -273,10 +633,28 @@ func main() {
 		}
 	}
 
-	select {} // keeps program running
+	// Keep program running until termination
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR1)
+	received := <-signals
+	// SIGUSR1 requests the ping status
+	for received == syscall.SIGUSR1 {
+		if pingTracker != nil {
+			pingTracker.LogStatus()
+		}
+		received = <-signals
+	}
+	config.printAndLog("Received " + received.String() + ", stopping at: " + time.Now().Format("15:04:05.00"))
+
+	// Record clean shutdown
+	if rebootTracker != nil {
+		rebootTracker.MarkClean()
+	}
 }
 
 func showHelp() {
 	fmt.Println("Usage: goTrack [OPTIONS]")
+	fmt.Println("       goTrack [OPTIONS] checkin\tRead the passphrase from stdin and record a check-in")
+	fmt.Println("       goTrack checkin-hash\tRead a passphrase from stdin and print its hash for checkin_passphrase_hash")
 	pflag.PrintDefaults()
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const modulesDisabledPath = "/proc/sys/kernel/modules_disabled"
+
+// ModuleTracker represents the kernel module tracking service
+type ModuleTracker struct {
+	Config        *Config
+	cachedModules map[string]bool
+	// disablePath is the sysctl file written to disable module loading
+	disablePath string
+}
+
+// NewModuleTracker creates a new ModuleTracker instance
+func NewModuleTracker(config *Config) *ModuleTracker {
+	return &ModuleTracker{
+		Config:      config,
+		disablePath: modulesDisabledPath,
+	}
+}
+
+// InitModules initializes the baseline of loaded modules. If configured, module loading is disabled once the baseline is read.
+func (m *ModuleTracker) InitModules(verbose, debug bool) {
+	modules, err := readModules(m.Config.ModulePath)
+	if err != nil {
+		m.Config.logErr(err)
+		m.cachedModules = make(map[string]bool)
+		return
+	}
+	if verbose {
+		fmt.Println("Loaded modules at start:")
+		for name := range modules {
+			fmt.Println(name)
+		}
+	}
+	if m.Config.ModuleBaseline {
+		m.cachedModules = modules
+	} else {
+		// Without baseline, modules loaded at start are checked against the allowlist on first tracking
+		m.cachedModules = make(map[string]bool)
+	}
+	if debug {
+		m.Config.log("Module baseline with " + strconv.Itoa(len(m.cachedModules)) + " modules")
+	}
+
+	if m.Config.ModuleDisableLoading {
+		if err := os.WriteFile(m.disablePath, []byte("1\n"), 0644); err != nil {
+			m.Config.log("Could not disable module loading")
+			m.Config.logErr(err)
+		} else {
+			m.Config.printAndLog("Module loading disabled")
+		}
+	}
+}
+
+// TrackModules tracks loaded kernel modules. Meant to be executed periodically
+func (m *ModuleTracker) TrackModules(noExec, debug bool) {
+	current, err := readModules(m.Config.ModulePath)
+	if err != nil {
+		m.Config.logErr(err)
+		if m.Config.ExecOnError {
+			m.Config.exec(debug, CalleeModule, -1, noExec)
+		}
+		return
+	}
+	if m.cachedModules == nil {
+		m.cachedModules = make(map[string]bool)
+	}
+
+	// Check for new modules
+	for name := range current {
+		if m.cachedModules[name] {
+			continue
+		}
+		if has(m.Config.ModuleAllowed, name) {
+			if debug {
+				m.Config.log("Allowed module loaded: " + name)
+			}
+		} else {
+			m.Config.log("New module loaded: " + name)
+			m.Config.execWithEnv(debug, CalleeModule, m.Config.ModuleCommandId, noExec, "GOTRACK_MODULE_NAME="+name, "GOTRACK_MODULE_EVENT=load")
+		}
+		m.cachedModules[name] = true
+	}
+
+	// Check for unloaded modules
+	for name := range m.cachedModules {
+		if current[name] {
+			continue
+		}
+		if has(m.Config.ModuleRequired, name) {
+			m.Config.log("Required module unloaded: " + name)
+			m.Config.execWithEnv(debug, CalleeModule, m.Config.ModuleCommandId, noExec, "GOTRACK_MODULE_NAME="+name, "GOTRACK_MODULE_EVENT=unload")
+		} else if debug {
+			m.Config.log("Module unloaded: " + name)
+		}
+		delete(m.cachedModules, name)
+	}
+}
+
+// readModules reads the names of all loaded modules in the format of /proc/modules
+func readModules(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	modules := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			modules[fields[0]] = true
+		}
+	}
+	return modules, nil
+}
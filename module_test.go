@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeModules writes a file in the format of /proc/modules
+func writeModules(t *testing.T, path string, names ...string) {
+	content := ""
+	for _, name := range names {
+		content += name + " 16384 0 - Live 0x0000000000000000\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Error writing modules: %v", err)
+	}
+}
+
+func Test_readModules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "modules")
+	writeModules(t, path, "dm_crypt", "ext4")
+	got, err := readModules(path)
+	if err != nil {
+		t.Fatalf("readModules() error = %v", err)
+	}
+	if want := map[string]bool{"dm_crypt": true, "ext4": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("readModules() = %v, want %v", got, want)
+	}
+	if _, err := readModules(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("readModules() on missing file, want error")
+	}
+}
+
+func TestModuleTracker_TrackModules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "modules")
+	tests := []struct {
+		name     string
+		baseline bool
+		start    []string
+		current  []string
+		want     map[string]bool
+	}{
+		{
+			name:     "Baseline unchanged",
+			baseline: true,
+			start:    []string{"dm_crypt", "ext4"},
+			current:  []string{"dm_crypt", "ext4"},
+			want:     map[string]bool{"dm_crypt": true, "ext4": true},
+		},
+		{
+			name:     "New and unloaded modules",
+			baseline: true,
+			start:    []string{"dm_crypt", "ext4"},
+			current:  []string{"ext4", "firewire_ohci"},
+			want:     map[string]bool{"ext4": true, "firewire_ohci": true},
+		},
+		{
+			name:     "Without baseline",
+			baseline: false,
+			start:    []string{"dm_crypt"},
+			current:  []string{"dm_crypt", "usb_storage"},
+			want:     map[string]bool{"dm_crypt": true, "usb_storage": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewConfig()
+			config.LogFile = ""
+			config.ModulePath = path
+			config.ModuleBaseline = tt.baseline
+			config.ModuleAllowed = []string{"usb_storage"}
+			config.ModuleRequired = []string{"dm_crypt"}
+			writeModules(t, path, tt.start...)
+			m := NewModuleTracker(config)
+			m.InitModules(false, false)
+			writeModules(t, path, tt.current...)
+			m.TrackModules(true, false)
+			if !reflect.DeepEqual(m.cachedModules, tt.want) {
+				t.Errorf("TrackModules() cache = %v, want %v", m.cachedModules, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleTracker_InitModules_disableLoading(t *testing.T) {
+	dir := t.TempDir()
+	config := NewConfig()
+	config.LogFile = ""
+	config.ModulePath = filepath.Join(dir, "modules")
+	config.ModuleDisableLoading = true
+	writeModules(t, config.ModulePath, "dm_crypt")
+	m := NewModuleTracker(config)
+	m.disablePath = filepath.Join(dir, "modules_disabled")
+	m.InitModules(false, false)
+	data, err := os.ReadFile(m.disablePath)
+	if err != nil || string(data) != "1\n" {
+		t.Errorf("InitModules() wrote %q, %v, want module loading disabled", data, err)
+	}
+}
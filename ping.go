@@ -1,11 +1,44 @@
 package main
 
-import "time"
-import "github.com/prometheus-community/pro-bing"
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus-community/pro-bing"
+)
+
+const PingTypeICMP = "icmp"
+const PingTypeTCP = "tcp"
+const PingTypeUDP = "udp"
+
+const PingModeLevel = "level"
+const PingModeTransition = "transition"
+
+const PingStateUp = "up"
+const PingStateDown = "down"
+
+// pingState represents the up/down state of a target in transition mode
+type pingState struct {
+	// current is empty until the first state is reached
+	current   string
+	failures  int
+	successes int
+}
 
 // PingTracker represents the Ping tracking service
 type PingTracker struct {
 	Config *Config
+	// states holds the state of targets in transition mode, keyed by their entry in Config.PingTrackingConfigs
+	states map[*PingTarget]*pingState
+	// responding holds the result of the last check of each target key for groups
+	responding map[string]bool
+	// history holds the last results of each target address
+	history    map[string]*pingHistory
+	statesLock sync.Mutex
+	schedule   schedule
 }
 
 const PingSuc uint8 = 0
@@ -19,76 +52,324 @@ func NewPingTracker(config *Config) *PingTracker {
 	return &PingTracker{Config: config}
 }
 
-// TrackPingTargets tracks ping targets. Meant to be executed periodically. Starts async pings for each config.
+// TrackPingTargets tracks ping targets. Meant to be executed every TickInterval. Starts async pings for each config that is due.
+// Groups are evaluated async after all pings of the round finished.
 func (p *PingTracker) TrackPingTargets(noExec, debug bool) uint {
 	var counter uint = 0
-	for _, config := range p.Config.PingTrackingConfigs {
-		go p.ping(noExec, debug, &config)
+	var wg sync.WaitGroup
+	now := time.Now()
+	tick := p.TickInterval()
+	// Targets are passed by their entry, so the state of transitions is kept across calls
+	for i := range p.Config.PingTrackingConfigs {
+		pingTarget := &p.Config.PingTrackingConfigs[i]
+		if !p.schedule.due(i, now, pingTarget.intervalOr(p.Config.PingInterval), tick) {
+			continue
+		}
+		wg.Add(1)
+		go func(pingTarget *PingTarget) {
+			defer wg.Done()
+			p.ping(noExec, debug, pingTarget)
+		}(pingTarget)
 		counter++
 	}
+	if counter > 0 && len(p.Config.PingGroups) > 0 {
+		go func() {
+			wg.Wait()
+			p.evaluateGroups(noExec, debug)
+		}()
+	}
+	return counter
+}
+
+// TickInterval returns the interval TrackPingTargets has to be called in, see tickInterval
+func (p *PingTracker) TickInterval() time.Duration {
+	var intervals []time.Duration
+	for _, pingTarget := range p.Config.PingTrackingConfigs {
+		intervals = append(intervals, pingTarget.intervalOr(p.Config.PingInterval))
+	}
+	return tickInterval(p.Config.PingInterval, intervals...)
+}
+
+// intervalOr returns the interval of the target or the global interval if not set
+func (t *PingTarget) intervalOr(global time.Duration) time.Duration {
+	if t.Interval > 0 {
+		return t.Interval
+	}
+	return global
+}
+
+// key identifies the target for groups and the history. TCP and UDP targets include type and port, so probes to the same
+// host do not overwrite each other
+func (t *PingTarget) key() string {
+	switch t.Type {
+	case PingTypeTCP, PingTypeUDP:
+		return t.Type + "://" + net.JoinHostPort(t.Target, strconv.Itoa(t.Port))
+	}
+	return t.Target
+}
+
+// evaluateGroups counts the responding targets of each group and executes the commands of groups meeting their condition. Returns the number of executions.
+func (p *PingTracker) evaluateGroups(noExec, debug bool) uint {
+	var counter uint = 0
+	for _, group := range p.Config.PingGroups {
+		responding := 0
+		p.statesLock.Lock()
+		for _, target := range group.Targets {
+			if p.responding[target] {
+				responding++
+			}
+		}
+		p.statesLock.Unlock()
+		if debug {
+			p.Config.log("Ping group " + group.Name + ": " + strconv.Itoa(responding) + " of " + strconv.Itoa(len(group.Targets)) + " responding")
+		}
+
+		if group.isTriggered(responding) {
+			counter++
+			p.Config.log("Ping group " + group.Name + " triggered with " + strconv.Itoa(responding) + " of " + strconv.Itoa(len(group.Targets)) + " responding")
+			p.Config.execWithEnv(debug, CalleePing, group.CommandId, noExec, "GOTRACK_PING_GROUP="+group.Name, "GOTRACK_PING_RESPONDING="+strconv.Itoa(responding))
+		}
+	}
 	return counter
 }
 
+// isTriggered checks if the number of responding targets meets the condition of the group
+func (g PingGroup) isTriggered(responding int) bool {
+	// At least one target must respond if not set
+	quorum := max(g.Quorum, 1)
+	if g.OnSuccess {
+		return responding >= quorum
+	}
+	return responding < quorum
+}
+
 // ping executes the ping and decides for executions calls. Meant to be executed async.
 func (p *PingTracker) ping(noExec, debug bool, pingTarget *PingTarget) uint8 {
-	pinger, err := probing.NewPinger(pingTarget.Target)
-	if err != nil {
-		p.Config.log(err.Error())
+	success := false
+	reason := ""
+	var stats *probing.Statistics
+	// Errors count as not responding
+	defer func() {
+		p.statesLock.Lock()
+		if p.responding == nil {
+			p.responding = make(map[string]bool)
+		}
+		p.responding[pingTarget.key()] = success
+		p.statesLock.Unlock()
+		p.record(newPingSample(pingTarget.key(), stats, success, reason))
+	}()
+
+	// Errors count as failures for the transition mode and keep the ExecOnError handling in level mode
+	var probeErr error
+	if pingTarget.PingTimeout == 0 {
+		probeErr = errors.New("timeout must be greater than zero")
+	}
+	for i := 0; probeErr == nil && i <= pingTarget.RetryCount; i++ {
+		if debug && i > 0 {
+			p.Config.log("Retrying: " + pingTarget.Target)
+		}
+		var err error
+		stats, err = probe(pingTarget)
+		if err != nil {
+			probeErr = err
+			break
+		}
+		reason = pingTarget.checkStatistics(stats)
+		success = len(reason) == 0
+		if debug {
+			p.Config.log("Ping statistics for " + pingTarget.Target + ": loss " + strconv.FormatFloat(stats.PacketLoss, 'f', 1, 64) + "% avg " + stats.AvgRtt.String() + " max " + stats.MaxRtt.String() + " jitter " + pingJitter(stats.Rtts).String())
+		}
+
+		// Failures are not retried for OnSuccess in level mode
+		if success || (pingTarget.OnSuccess && pingTarget.Mode != PingModeTransition) {
+			break
+		}
+		if debug {
+			p.Config.log("Pinging failed for: " + pingTarget.Target + " (" + reason + ")")
+		}
+		// Wait
+		time.Sleep(pingTarget.RetryDelay)
+	}
+	if probeErr != nil {
+		stats = nil
+		success = false
+		reason = probeErr.Error()
+		p.Config.log("Ping of " + pingTarget.Target + " failed: " + reason)
+	}
+
+	if pingTarget.GroupOnly {
+		if success {
+			return PingSuc
+		}
+		return PingNoSuc
+	}
+	if pingTarget.Mode == PingModeTransition {
+		return p.transition(noExec, debug, pingTarget, success, reason)
+	}
+	if probeErr != nil {
 		if p.Config.ExecOnError {
 			p.Config.exec(debug, CalleePing, pingTarget.CommandId, noExec)
 			return PingExec
 		}
+		if pingTarget.PingTimeout == 0 {
+			return PingTimeoutErr
+		}
 		return PingErr
 	}
-	if pingTarget.PingTimeout == 0 {
-		p.Config.log("Timeout must be greater than zero")
-		if p.Config.ExecOnError {
+	if pingTarget.OnSuccess {
+		// If ping returns -> execute. Else return
+		if success {
+			p.Config.log("Ping successful. Execution started due to OnSuccess for " + pingTarget.Target)
 			p.Config.exec(debug, CalleePing, pingTarget.CommandId, noExec)
 			return PingExec
 		}
-		return PingTimeoutErr
+		return PingNoSuc
 	}
-	pinger.Count = 1
-	pinger.Timeout = pingTarget.PingTimeout
+	if success {
+		return PingSuc
+	}
+	p.Config.log("Ping unsuccessful after maximum retries. Execution started due failure for " + pingTarget.Target + " (" + reason + ")")
+	p.Config.execWithEnv(debug, CalleePing, pingTarget.CommandId, noExec, "GOTRACK_PING_TARGET="+pingTarget.Target, "GOTRACK_PING_REASON="+reason)
+	return PingExec
+}
 
-	for i := 0; i <= pingTarget.RetryCount; i++ {
-		if debug && i > 0 {
-			p.Config.log("Retrying: " + pingTarget.Target)
+// transition updates the up/down state of the target with hysteresis. Commands are executed on the transition to down,
+// or to up if OnSuccess is set. Reaching down first counts as transition, reaching up only counts after a down state.
+func (p *PingTracker) transition(noExec, debug bool, pingTarget *PingTarget, success bool, reason string) uint8 {
+	p.statesLock.Lock()
+	if p.states == nil {
+		p.states = make(map[*PingTarget]*pingState)
+	}
+	state, ok := p.states[pingTarget]
+	if !ok {
+		state = &pingState{}
+		p.states[pingTarget] = state
+	}
+	previous := state.current
+	changed := state.update(success, max(pingTarget.FailThreshold, 1), max(pingTarget.RecoverThreshold, 1))
+	current := state.current
+	p.statesLock.Unlock()
+
+	res := PingNoSuc
+	if success {
+		res = PingSuc
+	}
+	if !changed {
+		return res
+	}
+	p.Config.log("Ping target " + pingTarget.Target + " changed to " + current)
+	if (current == PingStateUp) != pingTarget.OnSuccess || (current == PingStateUp && previous != PingStateDown) {
+		return res
+	}
+	if current == PingStateUp {
+		reason = ""
+	}
+	p.Config.execWithEnv(debug, CalleePing, pingTarget.CommandId, noExec, "GOTRACK_PING_TARGET="+pingTarget.Target, "GOTRACK_PING_STATE="+current, "GOTRACK_PING_REASON="+reason)
+	return PingExec
+}
+
+// update counts consecutive results and changes the state after failThreshold failures or recoverThreshold successes. Returns true on a change.
+func (s *pingState) update(success bool, failThreshold, recoverThreshold int) bool {
+	if success {
+		s.successes++
+		s.failures = 0
+		if s.current != PingStateUp && s.successes >= recoverThreshold {
+			s.current = PingStateUp
+			return true
+		}
+	} else {
+		s.failures++
+		s.successes = 0
+		if s.current != PingStateDown && s.failures >= failThreshold {
+			s.current = PingStateDown
+			return true
 		}
+	}
+	return false
+}
 
-		err = pinger.Run()
+// probe executes a single try of the target depending on its type
+func probe(pingTarget *PingTarget) (*probing.Statistics, error) {
+	switch pingTarget.Type {
+	case "", PingTypeICMP:
+		// A pinger can only be run once
+		pinger, err := newPinger(pingTarget)
 		if err != nil {
-			p.Config.log(err.Error())
-			if p.Config.ExecOnError {
-				p.Config.exec(debug, CalleePing, pingTarget.CommandId, noExec)
-				return PingExec
-			}
-			return PingErr
+			return nil, err
+		}
+		if err := pinger.Run(); err != nil {
+			return nil, err
 		}
-		success := pinger.Statistics().PacketsRecv > 0
+		return pinger.Statistics(), nil
+	case PingTypeTCP, PingTypeUDP:
+		return socketProbe(pingTarget)
+	}
+	return nil, errors.New("unknown ping type: " + pingTarget.Type)
+}
 
-		// Check for Config
-		if pingTarget.OnSuccess {
-			// If ping returns -> execute. Else return
-			if success {
-				p.Config.log("Ping successful. Execution started due to OnSuccess for " + pingTarget.Target)
-				p.Config.exec(debug, CalleePing, pingTarget.CommandId, noExec)
-				return PingExec
-			}
-			return PingNoSuc
-		} else {
-			// If ping fails -> retry. Else return
-			if success {
-				return PingSuc
-			}
-			if debug {
-				p.Config.log("Pinging failed for: " + pingTarget.Target)
-			}
+// newPinger creates a pinger for a single try of the target
+func newPinger(pingTarget *PingTarget) (*probing.Pinger, error) {
+	pinger := probing.New(pingTarget.Target)
+	// The family must be set before the target is resolved
+	switch pingTarget.Family {
+	case "", "ip4", "ip6":
+		pinger.SetNetwork(pingTarget.Family)
+	default:
+		return nil, errors.New("unknown address family: " + pingTarget.Family)
+	}
+	pinger.SetPrivileged(pingTarget.Privileged)
+	pinger.Source = pingTarget.Source
+	pinger.InterfaceName = pingTarget.Interface
+	if pingTarget.TTL > 0 {
+		pinger.TTL = pingTarget.TTL
+	}
+	if pingTarget.Size > 0 {
+		pinger.Size = pingTarget.Size
+	}
+	pinger.Count = 1
+	if pingTarget.Count > 1 {
+		pinger.Count = pingTarget.Count
+	}
+	if pingTarget.PacketInterval > 0 {
+		pinger.Interval = pingTarget.PacketInterval
+	}
+	// PingTimeout is the time to wait for the reply to the last packet
+	pinger.Timeout = pingTarget.PingTimeout + time.Duration(pinger.Count-1)*pinger.Interval
+	return pinger, pinger.Resolve()
+}
+
+// checkStatistics checks the statistics of a try against the thresholds. Returns the reason of the first crossed threshold, empty if successful.
+func (t *PingTarget) checkStatistics(stats *probing.Statistics) string {
+	if stats.PacketsRecv == 0 {
+		return "no packets received"
+	}
+	if t.MaxLoss != nil && stats.PacketLoss > *t.MaxLoss {
+		return "packet loss " + strconv.FormatFloat(stats.PacketLoss, 'f', 1, 64) + "% above " + strconv.FormatFloat(*t.MaxLoss, 'f', 1, 64) + "%"
+	}
+	if t.MaxAvgRtt > 0 && stats.AvgRtt > t.MaxAvgRtt {
+		return "average rtt " + stats.AvgRtt.String() + " above " + t.MaxAvgRtt.String()
+	}
+	if t.MaxRtt > 0 && stats.MaxRtt > t.MaxRtt {
+		return "max rtt " + stats.MaxRtt.String() + " above " + t.MaxRtt.String()
+	}
+	if jitter := pingJitter(stats.Rtts); t.MaxJitter > 0 && jitter > t.MaxJitter {
+		return "jitter " + jitter.String() + " above " + t.MaxJitter.String()
+	}
+	return ""
+}
+
+// pingJitter returns the mean absolute difference between consecutive round-trip times
+func pingJitter(rtts []time.Duration) time.Duration {
+	if len(rtts) < 2 {
+		return 0
+	}
+	var sum time.Duration = 0
+	for i := 1; i < len(rtts); i++ {
+		diff := rtts[i] - rtts[i-1]
+		if diff < 0 {
+			diff = -diff
 		}
-		// Wait
-		time.Sleep(pingTarget.RetryDelay)
+		sum += diff
 	}
-	p.Config.log("Ping unsuccessful after maximum retries. Execution started due failure for " + pingTarget.Target)
-	p.Config.exec(debug, CalleePing, pingTarget.CommandId, noExec)
-	return PingExec
+	return sum / time.Duration(len(rtts)-1)
 }
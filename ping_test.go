@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/prometheus-community/pro-bing"
 )
 
 func TestNewPingTracker(t *testing.T) {
@@ -186,3 +188,208 @@ func TestPingTracker_ping(t *testing.T) {
 		})
 	}
 }
+
+func TestPingTarget_checkStatistics(t *testing.T) {
+	loss := 20.0
+	target := &PingTarget{MaxLoss: &loss, MaxAvgRtt: 100 * time.Millisecond, MaxRtt: 300 * time.Millisecond, MaxJitter: 50 * time.Millisecond}
+	tests := []struct {
+		name   string
+		target *PingTarget
+		stats  *probing.Statistics
+		want   string
+	}{
+		{
+			name:   "no thresholds",
+			target: &PingTarget{},
+			stats:  &probing.Statistics{PacketsRecv: 1, PacketLoss: 90, AvgRtt: 2 * time.Second, MaxRtt: 2 * time.Second},
+			want:   "",
+		},
+		{
+			name:   "nothing received",
+			target: &PingTarget{},
+			stats:  &probing.Statistics{PacketsRecv: 0, PacketLoss: 100},
+			want:   "no packets received",
+		},
+		{
+			name:   "within thresholds",
+			target: target,
+			stats:  &probing.Statistics{PacketsRecv: 9, PacketLoss: 10, AvgRtt: 20 * time.Millisecond, MaxRtt: 30 * time.Millisecond, Rtts: []time.Duration{10 * time.Millisecond, 30 * time.Millisecond}},
+			want:   "",
+		},
+		{
+			name:   "loss",
+			target: target,
+			stats:  &probing.Statistics{PacketsRecv: 4, PacketLoss: 60, AvgRtt: 20 * time.Millisecond, MaxRtt: 30 * time.Millisecond},
+			want:   "packet loss 60.0% above 20.0%",
+		},
+		{
+			name:   "average rtt",
+			target: target,
+			stats:  &probing.Statistics{PacketsRecv: 10, AvgRtt: 2 * time.Second, MaxRtt: 2 * time.Second},
+			want:   "average rtt 2s above 100ms",
+		},
+		{
+			name:   "max rtt",
+			target: target,
+			stats:  &probing.Statistics{PacketsRecv: 10, AvgRtt: 50 * time.Millisecond, MaxRtt: 400 * time.Millisecond},
+			want:   "max rtt 400ms above 300ms",
+		},
+		{
+			name:   "jitter",
+			target: target,
+			stats:  &probing.Statistics{PacketsRecv: 3, AvgRtt: 50 * time.Millisecond, MaxRtt: 100 * time.Millisecond, Rtts: []time.Duration{10 * time.Millisecond, 100 * time.Millisecond, 40 * time.Millisecond}},
+			want:   "jitter 75ms above 50ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.checkStatistics(tt.stats); got != tt.want {
+				t.Errorf("checkStatistics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPingTracker_transition(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  *PingTarget
+		results []bool
+		want    []uint8
+	}{
+		{
+			name:    "down after failures",
+			target:  &PingTarget{Target: "192.0.2.1", Mode: PingModeTransition, FailThreshold: 2, RecoverThreshold: 2},
+			results: []bool{true, false, false, false, true, false, true, true, false, false},
+			want:    []uint8{PingSuc, PingNoSuc, PingExec, PingNoSuc, PingSuc, PingNoSuc, PingSuc, PingSuc, PingNoSuc, PingExec},
+		},
+		{
+			name:    "down at start",
+			target:  &PingTarget{Target: "192.0.2.1", Mode: PingModeTransition},
+			results: []bool{false, false, true, false},
+			want:    []uint8{PingExec, PingNoSuc, PingSuc, PingExec},
+		},
+		{
+			name:    "up with OnSuccess",
+			target:  &PingTarget{Target: "192.0.2.1", Mode: PingModeTransition, OnSuccess: true, RecoverThreshold: 2},
+			results: []bool{true, true, true, false, true, true},
+			want:    []uint8{PingSuc, PingSuc, PingSuc, PingNoSuc, PingSuc, PingExec},
+		},
+		{
+			name:    "up with OnSuccess after down at start",
+			target:  &PingTarget{Target: "192.0.2.1", Mode: PingModeTransition, OnSuccess: true},
+			results: []bool{false, false, true, true},
+			want:    []uint8{PingNoSuc, PingNoSuc, PingExec, PingSuc},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PingTracker{Config: &Config{}}
+			for i, success := range tt.results {
+				if got := p.transition(true, false, tt.target, success, ""); got != tt.want[i] {
+					t.Errorf("transition() %d = %d, want %d", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPingTarget_key(t *testing.T) {
+	tests := []struct {
+		target PingTarget
+		want   string
+	}{
+		{target: PingTarget{Target: "192.0.2.1"}, want: "192.0.2.1"},
+		{target: PingTarget{Target: "192.0.2.1", Type: PingTypeICMP, Port: 80}, want: "192.0.2.1"},
+		{target: PingTarget{Target: "192.0.2.1", Type: PingTypeTCP, Port: 443}, want: "tcp://192.0.2.1:443"},
+		{target: PingTarget{Target: "2001:db8::1", Type: PingTypeUDP, Port: 53}, want: "udp://[2001:db8::1]:53"},
+	}
+	for _, tt := range tests {
+		if got := tt.target.key(); got != tt.want {
+			t.Errorf("key() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestPingTracker_evaluateGroups(t *testing.T) {
+	p := &PingTracker{
+		Config: &Config{PingGroups: []PingGroup{
+			{Name: "all unreachable", Targets: []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}, Quorum: 1, CommandId: -1},
+			{Name: "two responding", Targets: []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"}, Quorum: 2, OnSuccess: true, CommandId: -1},
+		}},
+	}
+	tests := []struct {
+		name       string
+		responding map[string]bool
+		want       uint
+	}{
+		{name: "none responding", responding: map[string]bool{"192.0.2.1": false, "192.0.2.2": false, "192.0.2.3": false}, want: 1},
+		{name: "one responding", responding: map[string]bool{"192.0.2.1": false, "192.0.2.2": true, "192.0.2.3": false}, want: 0},
+		{name: "two responding", responding: map[string]bool{"192.0.2.1": true, "192.0.2.4": true}, want: 1},
+		{name: "unknown targets", responding: map[string]bool{"192.0.2.9": true}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.responding = tt.responding
+			if got := p.evaluateGroups(true, false); got != tt.want {
+				t.Errorf("evaluateGroups() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPingGroup_isTriggered(t *testing.T) {
+	tests := []struct {
+		name       string
+		group      PingGroup
+		responding int
+		want       bool
+	}{
+		{name: "default quorum none responding", group: PingGroup{}, responding: 0, want: true},
+		{name: "default quorum one responding", group: PingGroup{}, responding: 1, want: false},
+		{name: "below quorum", group: PingGroup{Quorum: 3}, responding: 2, want: true},
+		{name: "quorum reached OnSuccess", group: PingGroup{Quorum: 2, OnSuccess: true}, responding: 2, want: true},
+		{name: "below quorum OnSuccess", group: PingGroup{Quorum: 2, OnSuccess: true}, responding: 1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.group.isTriggered(tt.responding); got != tt.want {
+				t.Errorf("isTriggered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newPinger(t *testing.T) {
+	tests := []struct {
+		name       string
+		pingTarget *PingTarget
+		wantErr    bool
+	}{
+		{name: "defaults", pingTarget: &PingTarget{Target: "127.0.0.1", PingTimeout: time.Second}, wantErr: false},
+		{name: "all options", pingTarget: &PingTarget{Target: "127.0.0.1", PingTimeout: time.Second, Count: 3, PacketInterval: 100 * time.Millisecond, Family: "ip4", Source: "127.0.0.1", Interface: "lo", TTL: 1, Size: 64, Privileged: true}, wantErr: false},
+		{name: "IPv6", pingTarget: &PingTarget{Target: "::1", PingTimeout: time.Second, Family: "ip6"}, wantErr: false},
+		{name: "family mismatch", pingTarget: &PingTarget{Target: "::1", PingTimeout: time.Second, Family: "ip4"}, wantErr: true},
+		{name: "unknown family", pingTarget: &PingTarget{Target: "127.0.0.1", PingTimeout: time.Second, Family: "ipx"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinger, err := newPinger(tt.pingTarget)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newPinger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if pinger.Privileged() != tt.pingTarget.Privileged || pinger.Source != tt.pingTarget.Source || pinger.InterfaceName != tt.pingTarget.Interface {
+				t.Errorf("newPinger() options not applied: privileged %v source %v interface %v", pinger.Privileged(), pinger.Source, pinger.InterfaceName)
+			}
+			if tt.pingTarget.TTL > 0 && pinger.TTL != tt.pingTarget.TTL || tt.pingTarget.Size > 0 && pinger.Size != tt.pingTarget.Size {
+				t.Errorf("newPinger() TTL = %v, Size = %v", pinger.TTL, pinger.Size)
+			}
+			if want := tt.pingTarget.PingTimeout + time.Duration(pinger.Count-1)*pinger.Interval; pinger.Timeout != want {
+				t.Errorf("newPinger() Timeout = %v, want %v", pinger.Timeout, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus-community/pro-bing"
+)
+
+const PingExportCSV = "csv"
+const PingExportJSONL = "jsonl"
+
+// pingExportHeader holds the columns of the CSV export
+var pingExportHeader = []string{"time", "target", "success", "loss", "avg_rtt_ms", "max_rtt_ms", "jitter_ms", "reason"}
+
+// pingSample represents the result of one check of a ping target
+type pingSample struct {
+	time time.Time
+	// target is the key of the target, so probes of different types to the same host are kept apart
+	target  string
+	success bool
+	// loss is the packet loss in percent, 100 if the check failed with an error
+	loss   float64
+	avgRtt time.Duration
+	maxRtt time.Duration
+	jitter time.Duration
+	reason string
+}
+
+// pingHistory holds the last samples of a ping target in a ring buffer
+type pingHistory struct {
+	samples     []pingSample
+	next        int
+	lastSuccess time.Time
+	lastFailure time.Time
+}
+
+// PingStatus represents the statistics of a ping target over its history
+type PingStatus struct {
+	// Target is the key of the target, the address for ICMP and tcp://host:port or udp://host:port otherwise
+	Target    string
+	Samples   int
+	Successes int
+	// Loss is the mean packet loss in percent
+	Loss float64
+	// AvgRtt is the mean of the average round trip times of all samples with received packets
+	AvgRtt      time.Duration
+	MaxRtt      time.Duration
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastReason  string
+}
+
+// newPingSample creates a sample from the statistics of a check. stats may be nil if the check failed with an error.
+func newPingSample(target string, stats *probing.Statistics, success bool, reason string) pingSample {
+	sample := pingSample{time: time.Now(), target: target, success: success, loss: 100, reason: reason}
+	if stats != nil {
+		sample.loss = stats.PacketLoss
+		sample.avgRtt = stats.AvgRtt
+		sample.maxRtt = stats.MaxRtt
+		sample.jitter = pingJitter(stats.Rtts)
+	}
+	return sample
+}
+
+// add stores the sample and drops the oldest one if size is reached
+func (h *pingHistory) add(sample pingSample, size int) {
+	if sample.success {
+		h.lastSuccess = sample.time
+	} else {
+		h.lastFailure = sample.time
+	}
+	if len(h.samples) < size {
+		h.samples = append(h.samples, sample)
+		return
+	}
+	if size < 1 {
+		return
+	}
+	h.samples[h.next%size] = sample
+	h.next = (h.next + 1) % size
+}
+
+// status calculates the statistics over all samples
+func (h *pingHistory) status(target string) PingStatus {
+	status := PingStatus{Target: target, Samples: len(h.samples), LastSuccess: h.lastSuccess, LastFailure: h.lastFailure}
+	var rttSum time.Duration
+	rttCount := 0
+	var newest time.Time
+	for _, sample := range h.samples {
+		status.Loss += sample.loss
+		if sample.success {
+			status.Successes++
+		}
+		if sample.loss < 100 {
+			rttSum += sample.avgRtt
+			rttCount++
+		}
+		status.MaxRtt = max(status.MaxRtt, sample.maxRtt)
+		if !sample.time.Before(newest) {
+			newest = sample.time
+			status.LastReason = sample.reason
+		}
+	}
+	if len(h.samples) > 0 {
+		status.Loss /= float64(len(h.samples))
+	}
+	if rttCount > 0 {
+		status.AvgRtt = rttSum / time.Duration(rttCount)
+	}
+	return status
+}
+
+// String returns the status as a single line
+func (s PingStatus) String() string {
+	line := s.Target + ": " + strconv.Itoa(s.Successes) + "/" + strconv.Itoa(s.Samples) + " successful, loss " +
+		strconv.FormatFloat(s.Loss, 'f', 1, 64) + "% avg " + s.AvgRtt.String() + " max " + s.MaxRtt.String()
+	if !s.LastSuccess.IsZero() {
+		line += " last success " + s.LastSuccess.Format(time.RFC3339)
+	}
+	if !s.LastFailure.IsZero() {
+		line += " last failure " + s.LastFailure.Format(time.RFC3339) + " (" + s.LastReason + ")"
+	}
+	return line
+}
+
+// record adds the sample to the history of its target and appends it to PingExportPath if configured
+func (p *PingTracker) record(sample pingSample) {
+	p.statesLock.Lock()
+	defer p.statesLock.Unlock()
+	if p.history == nil {
+		p.history = make(map[string]*pingHistory)
+	}
+	history, ok := p.history[sample.target]
+	if !ok {
+		history = &pingHistory{}
+		p.history[sample.target] = history
+	}
+	history.add(sample, p.Config.PingHistorySize)
+
+	if len(p.Config.PingExportPath) > 0 {
+		if err := exportPingSample(p.Config.PingExportPath, p.Config.PingExportFormat, sample); err != nil {
+			p.Config.logErr(err)
+		}
+	}
+}
+
+// Status returns the statistics of all targets with a history, in the order of the configured targets
+func (p *PingTracker) Status() []PingStatus {
+	p.statesLock.Lock()
+	defer p.statesLock.Unlock()
+	var statuses []PingStatus
+	seen := make(map[string]bool)
+	for i := range p.Config.PingTrackingConfigs {
+		key := p.Config.PingTrackingConfigs[i].key()
+		history, ok := p.history[key]
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		statuses = append(statuses, history.status(key))
+	}
+	return statuses
+}
+
+// LogStatus writes the statistics of all targets to the log
+func (p *PingTracker) LogStatus() {
+	for _, status := range p.Status() {
+		p.Config.printAndLog("Ping status " + status.String())
+	}
+}
+
+// exportPingSample appends the sample to path as CSV line or JSON line. A CSV header is written to new files.
+func exportPingSample(path, format string, sample pingSample) error {
+	createPath(path)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	if format == PingExportJSONL {
+		line, err := json.Marshal(map[string]any{
+			"time":       sample.time.Format(time.RFC3339Nano),
+			"target":     sample.target,
+			"success":    sample.success,
+			"loss":       sample.loss,
+			"avg_rtt_ms": ms(sample.avgRtt),
+			"max_rtt_ms": ms(sample.maxRtt),
+			"jitter_ms":  ms(sample.jitter),
+			"reason":     sample.reason,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(append(line, '\n'))
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		if err := writer.Write(pingExportHeader); err != nil {
+			return err
+		}
+	}
+	formatMs := func(d time.Duration) string {
+		return strconv.FormatFloat(ms(d), 'f', 3, 64)
+	}
+	if err := writer.Write([]string{
+		sample.time.Format(time.RFC3339Nano),
+		sample.target,
+		strconv.FormatBool(sample.success),
+		strconv.FormatFloat(sample.loss, 'f', 1, 64),
+		formatMs(sample.avgRtt),
+		formatMs(sample.maxRtt),
+		formatMs(sample.jitter),
+		sample.reason,
+	}); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/pro-bing"
+)
+
+func TestPingTracker_Status(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(target string, i int, success bool, loss float64, avgRtt time.Duration, reason string) pingSample {
+		return pingSample{time: start.Add(time.Duration(i) * time.Second), target: target, success: success, loss: loss, avgRtt: avgRtt, maxRtt: 2 * avgRtt, reason: reason}
+	}
+	p := NewPingTracker(&Config{
+		PingHistorySize:     3,
+		PingTrackingConfigs: []PingTarget{{Target: "gateway"}, {Target: "gateway", Type: PingTypeTCP, Port: 22}, {Target: "dns"}, {Target: "unchecked"}},
+	})
+	// The first sample is dropped as the history holds 3 samples
+	p.record(sample("gateway", 0, true, 0, 100*time.Millisecond, ""))
+	p.record(sample("gateway", 1, true, 0, 10*time.Millisecond, ""))
+	p.record(sample("gateway", 2, false, 100, 0, "packet loss"))
+	p.record(sample("gateway", 3, true, 50, 30*time.Millisecond, ""))
+	p.record(sample("tcp://gateway:22", 4, false, 100, 0, "connection refused"))
+	p.record(sample("dns", 0, false, 100, 0, "timeout"))
+
+	want := []PingStatus{
+		{Target: "gateway", Samples: 3, Successes: 2, Loss: 50, AvgRtt: 20 * time.Millisecond, MaxRtt: 60 * time.Millisecond, LastSuccess: start.Add(3 * time.Second), LastFailure: start.Add(2 * time.Second), LastReason: ""},
+		{Target: "tcp://gateway:22", Samples: 1, Successes: 0, Loss: 100, LastFailure: start.Add(4 * time.Second), LastReason: "connection refused"},
+		{Target: "dns", Samples: 1, Successes: 0, Loss: 100, LastFailure: start, LastReason: "timeout"},
+	}
+	got := p.Status()
+	if len(got) != len(want) {
+		t.Fatalf("Status() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Status()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if line := got[2].String(); !strings.Contains(line, "0/1 successful") || !strings.Contains(line, "(timeout)") {
+		t.Errorf("String() = %v", line)
+	}
+}
+
+func Test_newPingSample(t *testing.T) {
+	sample := newPingSample("gateway", nil, false, "no route")
+	if sample.loss != 100 || sample.success || sample.reason != "no route" {
+		t.Errorf("newPingSample() without statistics = %+v", sample)
+	}
+	stats := &probing.Statistics{PacketLoss: 25, AvgRtt: 2 * time.Millisecond, MaxRtt: 3 * time.Millisecond, Rtts: []time.Duration{time.Millisecond, 3 * time.Millisecond}}
+	sample = newPingSample("gateway", stats, true, "")
+	if sample.loss != 25 || sample.avgRtt != 2*time.Millisecond || sample.maxRtt != 3*time.Millisecond || sample.jitter != 2*time.Millisecond {
+		t.Errorf("newPingSample() = %+v", sample)
+	}
+}
+
+func Test_exportPingSample(t *testing.T) {
+	sample := pingSample{
+		time:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		target: "gateway",
+		loss:   100,
+		avgRtt: 1500 * time.Microsecond,
+		reason: "loss 100.0% > 20.0%, avg",
+	}
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "csv",
+			format: PingExportCSV,
+			want: "time,target,success,loss,avg_rtt_ms,max_rtt_ms,jitter_ms,reason\n" +
+				"2024-01-01T00:00:00Z,gateway,false,100.0,1.500,0.000,0.000,\"loss 100.0% > 20.0%, avg\"\n" +
+				"2024-01-01T00:00:00Z,gateway,false,100.0,1.500,0.000,0.000,\"loss 100.0% > 20.0%, avg\"\n",
+		},
+		{
+			name:   "jsonl",
+			format: PingExportJSONL,
+			want: "{\"avg_rtt_ms\":1.5,\"jitter_ms\":0,\"loss\":100,\"max_rtt_ms\":0,\"reason\":\"loss 100.0% \\u003e 20.0%, avg\",\"success\":false,\"target\":\"gateway\",\"time\":\"2024-01-01T00:00:00Z\"}\n" +
+				"{\"avg_rtt_ms\":1.5,\"jitter_ms\":0,\"loss\":100,\"max_rtt_ms\":0,\"reason\":\"loss 100.0% \\u003e 20.0%, avg\",\"success\":false,\"target\":\"gateway\",\"time\":\"2024-01-01T00:00:00Z\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "export", "ping."+tt.format)
+			for i := 0; i < 2; i++ {
+				if err := exportPingSample(path, tt.format, sample); err != nil {
+					t.Fatal(err)
+				}
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("exportPingSample() wrote %q, want %q", data, tt.want)
+			}
+			if tt.format == PingExportJSONL {
+				var line map[string]any
+				if err := json.Unmarshal([]byte(strings.Split(string(data), "\n")[0]), &line); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
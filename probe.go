@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/prometheus-community/pro-bing"
+	"golang.org/x/sys/unix"
+)
+
+// socketProbe connects to the port of a TCP target, or sends the payload to the port of a UDP target, Count times.
+// TCP probes are received if the connection is established. UDP probes are received if a response arrives,
+// or if ExpectResponse is false and the port is not reported as unreachable within PingTimeout.
+func socketProbe(pingTarget *PingTarget) (*probing.Statistics, error) {
+	if pingTarget.Port <= 0 || pingTarget.Port > 65535 {
+		return nil, errors.New("port must be between 1 and 65535 for " + pingTarget.Type + " probes")
+	}
+	network := pingTarget.Type
+	switch pingTarget.Family {
+	case "":
+	case "ip4":
+		network += "4"
+	case "ip6":
+		network += "6"
+	default:
+		return nil, errors.New("unknown address family: " + pingTarget.Family)
+	}
+
+	dialer := &net.Dialer{Timeout: pingTarget.PingTimeout, Control: pingTarget.controlSocket}
+	if len(pingTarget.Source) > 0 {
+		source := net.ParseIP(pingTarget.Source)
+		if source == nil {
+			return nil, errors.New("invalid source address: " + pingTarget.Source)
+		}
+		if pingTarget.Type == PingTypeTCP {
+			dialer.LocalAddr = &net.TCPAddr{IP: source}
+		} else {
+			dialer.LocalAddr = &net.UDPAddr{IP: source}
+		}
+	}
+	address := net.JoinHostPort(pingTarget.Target, strconv.Itoa(pingTarget.Port))
+
+	count := max(pingTarget.Count, 1)
+	interval := pingTarget.PacketInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	stats := &probing.Statistics{Addr: pingTarget.Target}
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		stats.PacketsSent++
+		rtt, received, err := pingTarget.socketTry(dialer, network, address)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			// Resolution failures are errors like for ICMP
+			return nil, err
+		}
+		if !received {
+			continue
+		}
+		stats.PacketsRecv++
+		if rtt > 0 {
+			stats.Rtts = append(stats.Rtts, rtt)
+		}
+	}
+
+	stats.PacketLoss = float64(stats.PacketsSent-stats.PacketsRecv) / float64(stats.PacketsSent) * 100
+	var sum time.Duration = 0
+	for i, rtt := range stats.Rtts {
+		if i == 0 || rtt < stats.MinRtt {
+			stats.MinRtt = rtt
+		}
+		if rtt > stats.MaxRtt {
+			stats.MaxRtt = rtt
+		}
+		sum += rtt
+	}
+	if len(stats.Rtts) > 0 {
+		stats.AvgRtt = sum / time.Duration(len(stats.Rtts))
+	}
+	return stats, nil
+}
+
+// socketTry executes a single connect or send. Returns the round-trip time, zero if no response was received.
+func (t *PingTarget) socketTry(dialer *net.Dialer, network, address string) (time.Duration, bool, error) {
+	start := time.Now()
+	conn, err := dialer.Dial(network, address)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+	if t.Type == PingTypeTCP {
+		return time.Since(start), true, nil
+	}
+
+	if err := conn.SetDeadline(start.Add(t.PingTimeout)); err != nil {
+		return 0, false, err
+	}
+	if _, err := conn.Write([]byte(t.Payload)); err != nil {
+		return 0, false, err
+	}
+	buffer := make([]byte, 65535)
+	_, err = conn.Read(buffer)
+	if err == nil {
+		return time.Since(start), true, nil
+	}
+	// An unreachable port is reported as refused connection on the next read
+	var netErr net.Error
+	if !t.ExpectResponse && errors.As(err, &netErr) && netErr.Timeout() {
+		return 0, true, nil
+	}
+	return 0, false, err
+}
+
+// controlSocket applies the Interface and TTL of the target to the socket before connecting
+func (t *PingTarget) controlSocket(network, _ string, conn syscall.RawConn) error {
+	var err error
+	controlErr := conn.Control(func(fd uintptr) {
+		if len(t.Interface) > 0 {
+			if err = unix.BindToDevice(int(fd), t.Interface); err != nil {
+				return
+			}
+		}
+		if t.TTL > 0 {
+			if network == "tcp6" || network == "udp6" {
+				err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_UNICAST_HOPS, t.TTL)
+			} else {
+				err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TTL, t.TTL)
+			}
+		}
+	})
+	if controlErr != nil {
+		return controlErr
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// freePort returns a port on localhost which is not in use for network
+func freePort(t *testing.T, network string) int {
+	if network == "tcp" {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Error listening: %v", err)
+		}
+		defer listener.Close()
+		return listener.Addr().(*net.TCPAddr).Port
+	}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func Test_socketProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	tcpPort := listener.Addr().(*net.TCPAddr).Port
+
+	// Echo server answering every payload except "silent"
+	echo, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	defer echo.Close()
+	go func() {
+		buffer := make([]byte, 1024)
+		for {
+			n, addr, err := echo.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if string(buffer[:n]) != "silent" {
+				_, _ = echo.WriteTo(buffer[:n], addr)
+			}
+		}
+	}()
+	udpPort := echo.LocalAddr().(*net.UDPAddr).Port
+
+	timeout := 200 * time.Millisecond
+	tests := []struct {
+		name       string
+		pingTarget *PingTarget
+		wantRecv   int
+		wantErr    bool
+	}{
+		{name: "tcp open", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeTCP, Port: tcpPort, PingTimeout: timeout, Count: 2, PacketInterval: time.Millisecond}, wantRecv: 2},
+		{name: "tcp closed", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeTCP, Port: freePort(t, "tcp"), PingTimeout: timeout}, wantRecv: 0},
+		{name: "tcp with TTL", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeTCP, Port: tcpPort, PingTimeout: timeout, TTL: 5, Source: "127.0.0.1"}, wantRecv: 1},
+		{name: "udp response", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeUDP, Port: udpPort, PingTimeout: timeout, Payload: "ping", ExpectResponse: true}, wantRecv: 1},
+		{name: "udp missing response", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeUDP, Port: udpPort, PingTimeout: timeout, Payload: "silent", ExpectResponse: true}, wantRecv: 0},
+		{name: "udp silent port", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeUDP, Port: udpPort, PingTimeout: timeout, Payload: "silent"}, wantRecv: 1},
+		{name: "udp unreachable port", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeUDP, Port: freePort(t, "udp"), PingTimeout: timeout, Payload: "ping"}, wantRecv: 0},
+		{name: "missing port", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeTCP, PingTimeout: timeout}, wantErr: true},
+		{name: "family mismatch", pingTarget: &PingTarget{Target: "127.0.0.1", Type: PingTypeTCP, Port: tcpPort, Family: "ip6", PingTimeout: timeout}, wantRecv: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := socketProbe(tt.pingTarget)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("socketProbe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if stats.PacketsRecv != tt.wantRecv {
+				t.Errorf("socketProbe() PacketsRecv = %v, want %v", stats.PacketsRecv, tt.wantRecv)
+			}
+			if want := float64(stats.PacketsSent-tt.wantRecv) / float64(stats.PacketsSent) * 100; stats.PacketLoss != want {
+				t.Errorf("socketProbe() PacketLoss = %v, want %v", stats.PacketLoss, want)
+			}
+		})
+	}
+}
+
+func TestPingTracker_ping_tcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	p := &PingTracker{Config: &Config{}}
+	if got := p.ping(true, false, &PingTarget{Target: "127.0.0.1", Type: PingTypeTCP, Port: port, PingTimeout: time.Second}); got != PingSuc {
+		t.Errorf("ping() = %d, want %d", got, PingSuc)
+	}
+	if got := p.ping(true, false, &PingTarget{Target: "127.0.0.1", Type: PingTypeTCP, Port: port, PingTimeout: time.Second, OnSuccess: true}); got != PingExec {
+		t.Errorf("ping() OnSuccess = %d, want %d", got, PingExec)
+	}
+	if !p.responding["tcp://127.0.0.1:"+strconv.Itoa(port)] || len(p.responding) != 1 {
+		t.Errorf("ping() responding = %v, want only the TCP key", p.responding)
+	}
+	if _, ok := p.history["tcp://127.0.0.1:"+strconv.Itoa(port)]; !ok || len(p.history) != 1 {
+		t.Errorf("ping() history = %v, want only the TCP key", p.history)
+	}
+	if got := p.ping(true, false, &PingTarget{Target: "127.0.0.1", Type: "sctp", PingTimeout: time.Second}); got != PingErr {
+		t.Errorf("ping() unknown type = %d, want %d", got, PingErr)
+	}
+
+	// Targets only evaluated by groups never execute, not even on errors
+	p = &PingTracker{Config: &Config{ExecOnError: true}}
+	if got := p.ping(true, false, &PingTarget{Target: "127.0.0.1", Type: "sctp", PingTimeout: time.Second, GroupOnly: true}); got != PingNoSuc {
+		t.Errorf("ping() GroupOnly error = %d, want %d", got, PingNoSuc)
+	}
+
+	// Errors are failures in transition mode and do not bypass the thresholds
+	p = &PingTracker{Config: &Config{ExecOnError: true}}
+	target := &PingTarget{Target: "127.0.0.1", Type: "sctp", PingTimeout: time.Second, Mode: PingModeTransition, FailThreshold: 2}
+	for i, want := range []uint8{PingNoSuc, PingExec, PingNoSuc} {
+		if got := p.ping(true, false, target); got != want {
+			t.Errorf("ping() transition error %d = %d, want %d", i, got, want)
+		}
+	}
+	target = &PingTarget{Target: "127.0.0.1", Mode: PingModeTransition, OnSuccess: true}
+	if got := p.ping(true, false, target); got != PingNoSuc {
+		t.Errorf("ping() transition zero timeout = %d, want %d", got, PingNoSuc)
+	}
+}
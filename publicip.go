@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const PublicIPNoExec uint8 = 0
+const PublicIPChanged uint8 = 1
+const PublicIPNotAllowed uint8 = 2
+const PublicIPFailed uint8 = 3
+
+const stunPrefix = "stun:"
+const stunMagicCookie uint32 = 0x2112A442
+const stunBindingRequest uint16 = 0x0001
+const stunBindingSuccess uint16 = 0x0101
+const stunMappedAddress uint16 = 0x0001
+const stunXorMappedAddress uint16 = 0x0020
+
+// PublicIPTracker represents the egress IP tracking service
+type PublicIPTracker struct {
+	Config *Config
+	// web provides the HTTP client for "what is my IP" endpoints
+	web  *WebTracker
+	lock sync.Mutex
+	// last maps the index of a target to the last seen address
+	last map[int]string
+}
+
+// NewPublicIPTracker creates a new PublicIPTracker instance
+func NewPublicIPTracker(config *Config) *PublicIPTracker {
+	return &PublicIPTracker{
+		Config: config,
+		web:    NewWebTracker(config),
+		last:   make(map[int]string),
+	}
+}
+
+// TrackPublicIPTargets checks the public IP of all targets. Meant to be executed periodically. Returns the number of executions.
+func (p *PublicIPTracker) TrackPublicIPTargets(noExec, debug bool) uint {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var counter uint = 0
+	for i, target := range p.Config.PublicIPTrackingConfigs {
+		if p.trackPublicIPTarget(noExec, debug, i, target) != PublicIPNoExec {
+			counter++
+		}
+	}
+	return counter
+}
+
+// trackPublicIPTarget compares the public IP of a target to the allowed ranges and the previously seen address
+func (p *PublicIPTracker) trackPublicIPTarget(noExec, debug bool, i int, target PublicIPTarget) uint8 {
+	address, err := p.lookup(debug, target)
+	if err != nil {
+		p.Config.logErr(err)
+		if p.Config.ExecOnError {
+			p.Config.execWithEnv(debug, CalleePublicIP, target.CommandId, noExec, "GOTRACK_PUBLIC_IP_SOURCE="+target.Target, "GOTRACK_PUBLIC_IP_REASON=failed")
+			return PublicIPFailed
+		}
+		return PublicIPNoExec
+	}
+	if debug {
+		p.Config.log("Public IP via " + target.Target + ": " + address.String())
+	}
+
+	previous, seen := p.last[i]
+	p.last[i] = address.String()
+
+	returnValue := PublicIPNoExec
+	reason := ""
+	if len(target.AllowedCIDRs) > 0 && !inCIDRs(target.AllowedCIDRs, address, p.Config) {
+		returnValue = PublicIPNotAllowed
+		reason = "not allowed"
+	} else if target.OnChange && seen && previous != address.String() {
+		returnValue = PublicIPChanged
+		reason = "changed"
+	}
+
+	if returnValue != PublicIPNoExec {
+		p.Config.log("Executing on public IP tracking for: " + target.Target + " address: " + address.String() + " reason: " + reason)
+		p.Config.execWithEnv(debug, CalleePublicIP, target.CommandId, noExec,
+			"GOTRACK_PUBLIC_IP_SOURCE="+target.Target,
+			"GOTRACK_PUBLIC_IP_ADDRESS="+address.String(),
+			"GOTRACK_PUBLIC_IP_PREVIOUS="+previous,
+			"GOTRACK_PUBLIC_IP_REASON="+reason,
+		)
+	}
+	return returnValue
+}
+
+// lookup returns the public IP from a STUN server for targets like "stun:host:port" or from a "what is my IP" HTTP endpoint
+func (p *PublicIPTracker) lookup(debug bool, target PublicIPTarget) (net.IP, error) {
+	if server, found := strings.CutPrefix(target.Target, stunPrefix); found {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "3478")
+		}
+		var address net.IP
+		var err error
+		for i := 0; i <= target.RetryCount; i++ {
+			if address, err = stunBinding(server, target.timeout()); err == nil {
+				return address, nil
+			}
+			time.Sleep(target.RetryDelay)
+		}
+		return nil, err
+	}
+
+	wC := p.web.curl(debug, WebTarget{Target: target.Target, RetryCount: target.RetryCount, RetryDelay: target.RetryDelay})
+	if wC.status != 200 {
+		return nil, errors.New("no public IP received from " + target.Target)
+	}
+	address := net.ParseIP(strings.TrimSpace(wC.content))
+	if address == nil {
+		return nil, errors.New("invalid public IP received from " + target.Target + ": " + strings.TrimSpace(wC.content))
+	}
+	return address, nil
+}
+
+// timeout returns the time to wait for a STUN response, 5s if not set
+func (t PublicIPTarget) timeout() time.Duration {
+	if t.Timeout == 0 {
+		return 5 * time.Second
+	}
+	return t.Timeout
+}
+
+// inCIDRs checks if address is in any of the ranges. Invalid ranges are logged and ignored.
+func inCIDRs(cidrs []string, address net.IP, config *Config) bool {
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			config.logErr(err)
+			continue
+		}
+		if network.Contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// stunBinding sends a STUN binding request (RFC 5389) and returns the mapped address of the response
+func stunBinding(server string, timeout time.Duration) (net.IP, error) {
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	// Header of type, length, magic cookie and transaction id without attributes
+	request := make([]byte, 20)
+	binary.BigEndian.PutUint16(request[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(request[4:], stunMagicCookie)
+	if _, err := rand.Read(request[8:20]); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, 1500)
+	n, err := conn.Read(response)
+	if err != nil {
+		return nil, err
+	}
+	return parseStunResponse(response[:n], request[8:20])
+}
+
+// parseStunResponse returns the XOR-MAPPED-ADDRESS or, for old servers, the MAPPED-ADDRESS of a binding response
+func parseStunResponse(response, transactionId []byte) (net.IP, error) {
+	if len(response) < 20 || binary.BigEndian.Uint16(response[0:]) != stunBindingSuccess ||
+		binary.BigEndian.Uint32(response[4:]) != stunMagicCookie || string(response[8:20]) != string(transactionId) {
+		return nil, errors.New("invalid STUN response")
+	}
+	end := 20 + int(binary.BigEndian.Uint16(response[2:]))
+	if end > len(response) {
+		return nil, errors.New("truncated STUN response")
+	}
+
+	var mapped net.IP
+	for offset := 20; offset+4 <= end; {
+		attribute := binary.BigEndian.Uint16(response[offset:])
+		length := int(binary.BigEndian.Uint16(response[offset+2:]))
+		value := response[offset+4:]
+		if offset+4+length > end {
+			return nil, errors.New("truncated STUN attribute")
+		}
+		value = value[:length]
+		// Attributes are padded to 4 bytes
+		offset += 4 + (length+3)&^3
+
+		// Value holds a reserved byte, the family, the port and the address
+		if len(value) < 8 || (attribute != stunXorMappedAddress && attribute != stunMappedAddress) {
+			continue
+		}
+		address := make(net.IP, len(value)-4)
+		copy(address, value[4:])
+		if len(address) != net.IPv4len && len(address) != net.IPv6len {
+			continue
+		}
+		if attribute == stunXorMappedAddress {
+			// The address is XORed with the magic cookie followed by the transaction id
+			key := append(binary.BigEndian.AppendUint32(nil, stunMagicCookie), transactionId...)
+			for i := range address {
+				address[i] ^= key[i]
+			}
+			return address, nil
+		}
+		mapped = address
+	}
+	if mapped == nil {
+		return nil, errors.New("no mapped address in STUN response")
+	}
+	return mapped, nil
+}
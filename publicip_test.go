@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// startStunServer starts a local STUN server answering binding requests with address as XOR-MAPPED-ADDRESS
+func startStunServer(t *testing.T, address net.IP) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if n < 20 {
+				continue
+			}
+			key := append(binary.BigEndian.AppendUint32(nil, stunMagicCookie), buffer[8:20]...)
+			value := []byte{0, 1, 0, 0}
+			if address.To4() == nil {
+				value[1] = 2
+			} else {
+				address = address.To4()
+			}
+			for i, b := range address {
+				value = append(value, b^key[i])
+			}
+			response := binary.BigEndian.AppendUint16(nil, stunBindingSuccess)
+			response = binary.BigEndian.AppendUint16(response, uint16(4+len(value)))
+			response = append(response, buffer[4:20]...)
+			response = binary.BigEndian.AppendUint16(response, stunXorMappedAddress)
+			response = binary.BigEndian.AppendUint16(response, uint16(len(value)))
+			response = append(response, value...)
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestPublicIPTracker_trackPublicIPTarget(t *testing.T) {
+	address := "203.0.113.7"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(address + "\n"))
+	}))
+	defer server.Close()
+	stun := startStunServer(t, net.ParseIP("2001:db8::7"))
+
+	tests := []struct {
+		name    string
+		target  PublicIPTarget
+		address string
+		want    []uint8
+	}{
+		{
+			name:    "allowed range",
+			target:  PublicIPTarget{Target: server.URL, AllowedCIDRs: []string{"203.0.113.0/24"}},
+			address: "203.0.113.7",
+			want:    []uint8{PublicIPNoExec},
+		},
+		{
+			name:    "not allowed range",
+			target:  PublicIPTarget{Target: server.URL, AllowedCIDRs: []string{"invalid", "198.51.100.0/24"}},
+			address: "203.0.113.7",
+			want:    []uint8{PublicIPNotAllowed, PublicIPNotAllowed},
+		},
+		{
+			name:    "unchanged",
+			target:  PublicIPTarget{Target: server.URL, OnChange: true},
+			address: "203.0.113.7",
+			want:    []uint8{PublicIPNoExec, PublicIPNoExec},
+		},
+		{
+			name:    "invalid answer",
+			target:  PublicIPTarget{Target: server.URL, OnChange: true},
+			address: "<html>",
+			want:    []uint8{PublicIPFailed},
+		},
+		{
+			name:   "STUN allowed range",
+			target: PublicIPTarget{Target: stunPrefix + stun, AllowedCIDRs: []string{"2001:db8::/32"}},
+			want:   []uint8{PublicIPNoExec},
+		},
+		{
+			name:   "STUN not allowed range",
+			target: PublicIPTarget{Target: stunPrefix + stun, AllowedCIDRs: []string{"203.0.113.0/24"}},
+			want:   []uint8{PublicIPNotAllowed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address = tt.address
+			p := NewPublicIPTracker(&Config{ExecOnError: true})
+			for i, want := range tt.want {
+				if got := p.trackPublicIPTarget(true, false, 0, tt.target); got != want {
+					t.Errorf("trackPublicIPTarget() call %d = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("changed", func(t *testing.T) {
+		p := NewPublicIPTracker(&Config{})
+		target := PublicIPTarget{Target: server.URL, OnChange: true}
+		address = "203.0.113.7"
+		if got := p.trackPublicIPTarget(true, false, 0, target); got != PublicIPNoExec {
+			t.Errorf("trackPublicIPTarget() first = %v, want %v", got, PublicIPNoExec)
+		}
+		address = "203.0.113.8"
+		if got := p.trackPublicIPTarget(true, false, 0, target); got != PublicIPChanged {
+			t.Errorf("trackPublicIPTarget() after change = %v, want %v", got, PublicIPChanged)
+		}
+		if got := p.trackPublicIPTarget(true, false, 0, target); got != PublicIPNoExec {
+			t.Errorf("trackPublicIPTarget() after change again = %v, want %v", got, PublicIPNoExec)
+		}
+	})
+}
+
+func Test_parseStunResponse(t *testing.T) {
+	transactionId := []byte("0123456789ab")
+	header := func(length uint16) []byte {
+		response := binary.BigEndian.AppendUint16(nil, stunBindingSuccess)
+		response = binary.BigEndian.AppendUint16(response, length)
+		response = binary.BigEndian.AppendUint32(response, stunMagicCookie)
+		return append(response, transactionId...)
+	}
+	mapped := append(header(12), 0, byte(stunMappedAddress), 0, 8, 0, 1, 0x0d, 0x96, 192, 0, 2, 1)
+	tests := []struct {
+		name     string
+		response []byte
+		want     string
+		wantErr  bool
+	}{
+		{name: "mapped address", response: mapped, want: "192.0.2.1"},
+		{name: "no address", response: header(0), wantErr: true},
+		{name: "truncated", response: header(12), wantErr: true},
+		{name: "other transaction", response: append(header(0)[:19], 'x'), wantErr: true},
+		{name: "too short", response: []byte{1, 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStunResponse(tt.response, transactionId)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStunResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("parseStunResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
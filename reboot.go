@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const bootIdPath = "/proc/sys/kernel/random/boot_id"
+const uptimePath = "/proc/uptime"
+
+const RebootNone uint8 = 0
+const RebootFirstRun uint8 = 1
+const RebootUnexpected uint8 = 2
+const RebootUncleanExit uint8 = 3
+const RebootClean uint8 = 4
+
+// bootState represents the state of the last goTrack run persisted to RebootStatePath
+type bootState struct {
+	bootId   string
+	bootTime time.Time
+	clean    bool
+}
+
+// RebootTracker represents the detection of unexpected reboots
+type RebootTracker struct {
+	Config     *Config
+	bootIdPath string
+	uptimePath string
+	current    bootState
+}
+
+// NewRebootTracker creates a new RebootTracker instance
+func NewRebootTracker(config *Config) *RebootTracker {
+	return &RebootTracker{
+		Config:     config,
+		bootIdPath: bootIdPath,
+		uptimePath: uptimePath,
+	}
+}
+
+// CheckReboot compares the current boot with the state of the last run and marks this run as running.
+// Commands are executed if the system rebooted without a clean shutdown of goTrack, or if configured on any unclean exit.
+func (r *RebootTracker) CheckReboot(noExec, debug bool) uint8 {
+	r.current = r.readCurrentBoot()
+	previous, err := readBootState(r.Config.RebootStatePath)
+	res := RebootNone
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.Config.logErr(err)
+		}
+		r.Config.log("No previous boot state found")
+		res = RebootFirstRun
+	} else {
+		if debug {
+			r.Config.log("Previous boot: " + previous.bootId + " Clean: " + strconv.FormatBool(previous.clean))
+		}
+		rebooted := r.current.isOtherBoot(previous, r.Config.RebootBootTimeTolerance)
+		if rebooted && !previous.clean {
+			r.Config.printAndLog("Unexpected reboot detected. Previous boot: " + previous.bootId)
+			r.Config.execWithEnv(debug, CalleeReboot, r.Config.RebootCommandId, noExec, "GOTRACK_REBOOT_REASON=unexpected reboot", "GOTRACK_REBOOT_PREVIOUS_BOOT_ID="+previous.bootId)
+			res = RebootUnexpected
+		} else if !previous.clean {
+			r.Config.printAndLog("Previous run ended uncleanly without reboot")
+			if r.Config.RebootOnUncleanExit {
+				r.Config.execWithEnv(debug, CalleeReboot, r.Config.RebootCommandId, noExec, "GOTRACK_REBOOT_REASON=unclean exit", "GOTRACK_REBOOT_PREVIOUS_BOOT_ID="+previous.bootId)
+			}
+			res = RebootUncleanExit
+		} else if rebooted {
+			res = RebootClean
+		}
+	}
+
+	// Mark this run as running until MarkClean is called
+	r.current.clean = false
+	if err := writeBootState(r.Config.RebootStatePath, r.current); err != nil {
+		r.Config.logErr(err)
+	}
+	return res
+}
+
+// MarkClean records a clean shutdown of goTrack. Meant to be called on termination.
+func (r *RebootTracker) MarkClean() {
+	r.current.clean = true
+	if err := writeBootState(r.Config.RebootStatePath, r.current); err != nil {
+		r.Config.logErr(err)
+	}
+}
+
+// readCurrentBoot reads the boot_id and calculates the boot time from the uptime
+func (r *RebootTracker) readCurrentBoot() bootState {
+	state := bootState{}
+	if data, err := os.ReadFile(r.bootIdPath); err == nil {
+		state.bootId = strings.TrimSpace(string(data))
+	} else {
+		r.Config.logErr(err)
+	}
+	if data, err := os.ReadFile(r.uptimePath); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			if uptime, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				state.bootTime = time.Now().Add(-time.Duration(uptime * float64(time.Second))).Truncate(time.Second)
+			}
+		}
+	} else {
+		r.Config.logErr(err)
+	}
+	return state
+}
+
+// isOtherBoot checks if the state belongs to another boot than previous. The boot time calculated from uptime is used if a boot_id is missing.
+func (s bootState) isOtherBoot(previous bootState, tolerance time.Duration) bool {
+	if len(s.bootId) > 0 && len(previous.bootId) > 0 {
+		return s.bootId != previous.bootId
+	}
+	diff := s.bootTime.Sub(previous.bootTime)
+	return diff > tolerance || diff < -tolerance
+}
+
+// readBootState reads a state file with "key=value" lines
+func readBootState(path string) (bootState, error) {
+	state := bootState{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "boot_id":
+			state.bootId = value
+		case "boot_time":
+			state.bootTime, _ = time.Parse(time.RFC3339, value)
+		case "clean":
+			state.clean = value == "true"
+		}
+	}
+	return state, nil
+}
+
+// writeBootState writes the state file
+func writeBootState(path string, state bootState) error {
+	createPath(path)
+	content := "boot_id=" + state.bootId + "\n" +
+		"boot_time=" + state.bootTime.Format(time.RFC3339) + "\n" +
+		"clean=" + strconv.FormatBool(state.clean) + "\n"
+	return os.WriteFile(path, []byte(content), 0600)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRebootTracker_CheckReboot(t *testing.T) {
+	dir := t.TempDir()
+	bootId := filepath.Join(dir, "boot_id")
+	uptime := filepath.Join(dir, "uptime")
+	writeFile := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Error writing %s: %v", path, err)
+		}
+	}
+	writeFile(uptime, "120.50 200.00\n")
+
+	config := NewConfig()
+	config.LogFile = ""
+	config.RebootStatePath = filepath.Join(dir, "state", "boot.state")
+	newTracker := func() *RebootTracker {
+		r := NewRebootTracker(config)
+		r.bootIdPath = bootId
+		r.uptimePath = uptime
+		return r
+	}
+
+	steps := []struct {
+		name   string
+		bootId string
+		clean  bool
+		want   uint8
+	}{
+		{name: "First run", bootId: "a", clean: true, want: RebootFirstRun},
+		{name: "Restart after clean shutdown", bootId: "a", clean: false, want: RebootNone},
+		{name: "Restart after unclean exit", bootId: "a", clean: true, want: RebootUncleanExit},
+		{name: "Reboot after clean shutdown", bootId: "b", clean: false, want: RebootClean},
+		{name: "Reboot without clean shutdown", bootId: "c", clean: false, want: RebootUnexpected},
+	}
+	for _, step := range steps {
+		writeFile(bootId, step.bootId+"\n")
+		r := newTracker()
+		if got := r.CheckReboot(true, false); got != step.want {
+			t.Errorf("%s: CheckReboot() = %v, want %v", step.name, got, step.want)
+		}
+		if step.clean {
+			r.MarkClean()
+		}
+	}
+}
+
+func TestRebootTracker_CheckReboot_uncleanExit(t *testing.T) {
+	dir := t.TempDir()
+	bootId := filepath.Join(dir, "boot_id")
+	if err := os.WriteFile(bootId, []byte("a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(dir, "executed")
+	config := NewConfig()
+	config.LogFile = ""
+	config.RebootStatePath = filepath.Join(dir, "boot.state")
+	config.Commands = []Command{{Command: "touch", Args: []string{marker}, Reboot: true, Id: -1}}
+
+	tests := []struct {
+		name          string
+		onUncleanExit bool
+		want          bool
+	}{
+		{name: "Default executes on unclean exit", onUncleanExit: NewConfig().RebootOnUncleanExit, want: true},
+		{name: "Disabled", onUncleanExit: false, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Remove(marker)
+			config.RebootOnUncleanExit = tt.onUncleanExit
+			// The previous run is marked as running and never marked clean
+			if err := writeBootState(config.RebootStatePath, bootState{bootId: "a"}); err != nil {
+				t.Fatal(err)
+			}
+			r := NewRebootTracker(config)
+			r.bootIdPath = bootId
+			if got := r.CheckReboot(false, false); got != RebootUncleanExit {
+				t.Errorf("CheckReboot() = %v, want %v", got, RebootUncleanExit)
+			}
+			if got := fileExists(marker); got != tt.want {
+				t.Errorf("CheckReboot() executed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bootState_isOtherBoot(t *testing.T) {
+	bootTime := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		current  bootState
+		previous bootState
+		want     bool
+	}{
+		{name: "Same boot_id", current: bootState{bootId: "a", bootTime: bootTime}, previous: bootState{bootId: "a"}, want: false},
+		{name: "Other boot_id", current: bootState{bootId: "b"}, previous: bootState{bootId: "a"}, want: true},
+		{name: "Boot time within tolerance", current: bootState{bootTime: bootTime}, previous: bootState{bootTime: bootTime.Add(time.Second)}, want: false},
+		{name: "Boot time differs", current: bootState{bootTime: bootTime}, previous: bootState{bootTime: bootTime.Add(-time.Hour)}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.current.isOtherBoot(tt.previous, 10*time.Second); got != tt.want {
+				t.Errorf("isOtherBoot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// schedule decides which targets of a tracker are due, if the tracker ticks at the greatest common divisor of the intervals of its targets
+type schedule struct {
+	lock sync.Mutex
+	// next maps the index of a target to the time it is due again
+	next map[int]time.Time
+}
+
+// due checks if the target with index i is due at now and schedules its next run after interval.
+// Ticks up to half a tick early still count as due, as ticks are delivered late.
+func (s *schedule) due(i int, now time.Time, interval, tick time.Duration) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.next == nil {
+		s.next = make(map[int]time.Time)
+	}
+	if next, ok := s.next[i]; ok && now.Add(tick/2).Before(next) {
+		return false
+	}
+	s.next[i] = now.Add(interval)
+	return true
+}
+
+// minTickInterval limits the tick for intervals with a tiny common divisor, like 1001ms and 1000ms. Targets are then
+// checked up to half of it early.
+const minTickInterval = 100 * time.Millisecond
+
+// tickInterval returns the greatest common divisor of the positive intervals, so every interval is a multiple of the tick.
+// The tick is at least minTickInterval unless an interval is shorter. Returns fallback if there is none.
+func tickInterval(fallback time.Duration, intervals ...time.Duration) time.Duration {
+	var tick, smallest time.Duration
+	for _, interval := range intervals {
+		if interval <= 0 {
+			continue
+		}
+		if smallest <= 0 || interval < smallest {
+			smallest = interval
+		}
+		for interval > 0 {
+			tick, interval = interval, tick%interval
+		}
+	}
+	if tick <= 0 {
+		return fallback
+	}
+	return max(tick, min(minTickInterval, smallest))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_schedule_due(t *testing.T) {
+	start := time.Date(2000, 1, 20, 12, 0, 0, 0, time.UTC)
+	tick := 10 * time.Second
+	steps := []struct {
+		name     string
+		offset   time.Duration
+		interval time.Duration
+		want     bool
+	}{
+		{name: "First run", offset: 0, interval: 30 * time.Second, want: true},
+		{name: "Next tick", offset: 10 * time.Second, interval: 30 * time.Second, want: false},
+		{name: "Second tick", offset: 20 * time.Second, interval: 30 * time.Second, want: false},
+		{name: "Early tick", offset: 29*time.Second + 900*time.Millisecond, interval: 30 * time.Second, want: true},
+		{name: "Late tick", offset: 60*time.Second + 500*time.Millisecond, interval: 30 * time.Second, want: true},
+	}
+	s := &schedule{}
+	for _, step := range steps {
+		if got := s.due(0, start.Add(step.offset), step.interval, tick); got != step.want {
+			t.Errorf("%s: due() = %v, want %v", step.name, got, step.want)
+		}
+	}
+	if !s.due(1, start.Add(20*time.Second), 30*time.Second, tick) {
+		t.Errorf("Other target: due() = false, want true")
+	}
+}
+
+func Test_schedule_due_commonTick(t *testing.T) {
+	start := time.Date(2000, 1, 20, 12, 0, 0, 0, time.UTC)
+	intervals := []time.Duration{10 * time.Second, 15 * time.Second}
+	tick := tickInterval(time.Minute, intervals...)
+	s := &schedule{}
+	runs := make([][]time.Duration, len(intervals))
+	for offset := time.Duration(0); offset < time.Minute; offset += tick {
+		for i, interval := range intervals {
+			if s.due(i, start.Add(offset), interval, tick) {
+				runs[i] = append(runs[i], offset)
+			}
+		}
+	}
+	for i, interval := range intervals {
+		for j, offset := range runs[i] {
+			if offset != time.Duration(j)*interval {
+				t.Errorf("Target %d: run %d at %v, want %v", i, j, offset, time.Duration(j)*interval)
+			}
+		}
+		if want := int(time.Minute / interval); len(runs[i]) != want {
+			t.Errorf("Target %d: %d runs, want %d", i, len(runs[i]), want)
+		}
+	}
+}
+
+func Test_schedule_due_minimumTick(t *testing.T) {
+	start := time.Date(2000, 1, 20, 12, 0, 0, 0, time.UTC)
+	intervals := []time.Duration{1000 * time.Millisecond, 1001 * time.Millisecond}
+	tick := tickInterval(time.Minute, intervals...)
+	s := &schedule{}
+	last := make([]time.Duration, len(intervals))
+	runs := make([]int, len(intervals))
+	for offset := time.Duration(0); offset < 10*time.Second; offset += tick {
+		for i, interval := range intervals {
+			if !s.due(i, start.Add(offset), interval, tick) {
+				continue
+			}
+			// Runs are at most half a tick early and less than a tick late
+			if runs[i] > 0 && (offset-last[i] < interval-tick/2 || offset-last[i] >= interval+tick) {
+				t.Errorf("Target %d: run after %v, want about %v", i, offset-last[i], interval)
+			}
+			last[i] = offset
+			runs[i]++
+		}
+	}
+	for i, count := range runs {
+		if count != 10 {
+			t.Errorf("Target %d: %d runs, want 10", i, count)
+		}
+	}
+}
+
+func Test_tickInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		fallback  time.Duration
+		intervals []time.Duration
+		want      time.Duration
+	}{
+		{name: "No targets", fallback: 10 * time.Second, intervals: nil, want: 10 * time.Second},
+		{name: "Smallest target", fallback: 10 * time.Second, intervals: []time.Duration{time.Minute, 2 * time.Second}, want: 2 * time.Second},
+		{name: "Common divisor", fallback: 10 * time.Second, intervals: []time.Duration{10 * time.Second, 15 * time.Second}, want: 5 * time.Second},
+		{name: "Minimum tick", fallback: 10 * time.Second, intervals: []time.Duration{1001 * time.Millisecond, time.Second}, want: minTickInterval},
+		{name: "Shorter than minimum", fallback: 10 * time.Second, intervals: []time.Duration{time.Second, 10 * time.Millisecond, 15 * time.Millisecond}, want: 10 * time.Millisecond},
+		{name: "Ignore zero", fallback: 10 * time.Second, intervals: []time.Duration{0, time.Minute}, want: time.Minute},
+		{name: "Only zero", fallback: 10 * time.Second, intervals: []time.Duration{0}, want: 10 * time.Second},
+		{name: "No fallback", fallback: 0, intervals: []time.Duration{time.Minute}, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tickInterval(tt.fallback, tt.intervals...); got != tt.want {
+				t.Errorf("tickInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPingTracker_TrackPingTargets_intervals(t *testing.T) {
+	p := &PingTracker{Config: &Config{
+		PingInterval: time.Hour,
+		PingTrackingConfigs: []PingTarget{
+			{Target: "127.0.0.1", PingTimeout: 0},
+			{Target: "127.0.0.1", PingTimeout: 0, Interval: time.Millisecond},
+		},
+	}}
+	if got := p.TickInterval(); got != time.Millisecond {
+		t.Errorf("TickInterval() = %v, want %v", got, time.Millisecond)
+	}
+	if got := p.TrackPingTargets(true, false); got != 2 {
+		t.Errorf("TrackPingTargets() first = %d, want 2", got)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if got := p.TrackPingTargets(true, false); got != 1 {
+		t.Errorf("TrackPingTargets() second = %d, want 1", got)
+	}
+}
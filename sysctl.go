@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const procSysPath = "/proc/sys"
+
+// SysctlTracker represents the sysctl drift tracking service
+type SysctlTracker struct {
+	Config *Config
+	// root is the directory holding the sysctl files
+	root    string
+	drifted map[string]bool
+}
+
+const SysctlOk uint8 = 0
+const SysctlDrift uint8 = 1
+const SysctlExec uint8 = 2
+const SysctlErr uint8 = 3
+
+// NewSysctlTracker creates a new SysctlTracker instance
+func NewSysctlTracker(config *Config) *SysctlTracker {
+	return &SysctlTracker{
+		Config:  config,
+		root:    procSysPath,
+		drifted: make(map[string]bool),
+	}
+}
+
+// TrackSysctls checks all configured sysctl keys. Meant to be executed periodically. Returns the number of drifted keys.
+func (s *SysctlTracker) TrackSysctls(noExec, debug bool) uint {
+	var counter uint = 0
+	for _, target := range s.Config.SysctlTrackingConfigs {
+		if res := s.checkSysctl(noExec, debug, target); res == SysctlDrift || res == SysctlExec {
+			counter++
+		}
+	}
+	return counter
+}
+
+// checkSysctl compares the value of a key with its expectation. Commands are only executed if the key drifts, not while it stays drifted.
+func (s *SysctlTracker) checkSysctl(noExec, debug bool, target SysctlTarget) uint8 {
+	value, err := s.read(target.Key)
+	if err != nil {
+		s.Config.logErr(err)
+		if s.Config.ExecOnError {
+			s.Config.exec(debug, CalleeSysctl, -1, noExec)
+			return SysctlExec
+		}
+		return SysctlErr
+	}
+	if debug {
+		s.Config.log("Sysctl " + target.Key + " = " + value)
+	}
+
+	if target.isExpected(value) {
+		if s.drifted[target.Key] {
+			s.Config.log("Sysctl restored: " + target.Key + " = " + value)
+			delete(s.drifted, target.Key)
+		}
+		return SysctlOk
+	}
+	if s.drifted[target.Key] {
+		return SysctlDrift
+	}
+	s.drifted[target.Key] = true
+	s.Config.log("Sysctl drifted: " + target.Key + " = " + value)
+	s.Config.execWithEnv(debug, CalleeSysctl, target.CommandId, noExec, "GOTRACK_SYSCTL_KEY="+target.Key, "GOTRACK_SYSCTL_VALUE="+value)
+	return SysctlExec
+}
+
+// read returns the value of a key like "kernel.kptr_restrict" with normalized whitespace
+func (s *SysctlTracker) read(key string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.root, strings.ReplaceAll(key, ".", "/")))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(string(data)), " "), nil
+}
+
+// isExpected checks a value against Expected, Min and Max
+func (t SysctlTarget) isExpected(value string) bool {
+	if len(t.Expected) > 0 && strings.Join(strings.Fields(t.Expected), " ") != value {
+		return false
+	}
+	if t.Min == nil && t.Max == nil {
+		return true
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	if t.Min != nil && number < *t.Min {
+		return false
+	}
+	if t.Max != nil && number > *t.Max {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSysctl writes the value of a key below root
+func writeSysctl(t *testing.T, root, path, value string) {
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0700); err != nil {
+		t.Fatalf("Error creating sysctl dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, path), []byte(value), 0600); err != nil {
+		t.Fatalf("Error writing sysctl: %v", err)
+	}
+}
+
+func TestSysctlTarget_isExpected(t *testing.T) {
+	one := int64(1)
+	three := int64(3)
+	tests := []struct {
+		name   string
+		target SysctlTarget
+		value  string
+		want   bool
+	}{
+		{name: "No expectation", target: SysctlTarget{}, value: "0", want: true},
+		{name: "Expected", target: SysctlTarget{Expected: "0"}, value: "0", want: true},
+		{name: "Not expected", target: SysctlTarget{Expected: "0"}, value: "1", want: false},
+		{name: "Expected whitespace", target: SysctlTarget{Expected: "4\t4  1 7"}, value: "4 4 1 7", want: true},
+		{name: "In range", target: SysctlTarget{Min: &one, Max: &three}, value: "2", want: true},
+		{name: "Below min", target: SysctlTarget{Min: &one}, value: "0", want: false},
+		{name: "Above max", target: SysctlTarget{Max: &three}, value: "4", want: false},
+		{name: "Not numeric", target: SysctlTarget{Min: &one}, value: "abc", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.isExpected(tt.value); got != tt.want {
+				t.Errorf("isExpected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSysctlTracker_checkSysctl(t *testing.T) {
+	root := t.TempDir()
+	one := int64(1)
+	config := NewConfig()
+	config.LogFile = ""
+	config.ExecOnError = false
+	s := NewSysctlTracker(config)
+	s.root = root
+	target := SysctlTarget{Key: "kernel.yama.ptrace_scope", Min: &one, CommandId: -1}
+
+	tests := []struct {
+		name  string
+		value string
+		want  uint8
+	}{
+		{name: "Hardened", value: "1\n", want: SysctlOk},
+		{name: "Drift", value: "0\n", want: SysctlExec},
+		{name: "Still drifted", value: "0\n", want: SysctlDrift},
+		{name: "Restored", value: "2\n", want: SysctlOk},
+		{name: "Drift again", value: "0\n", want: SysctlExec},
+	}
+	for _, tt := range tests {
+		writeSysctl(t, root, "kernel/yama/ptrace_scope", tt.value)
+		if got := s.checkSysctl(true, false, target); got != tt.want {
+			t.Errorf("%s: checkSysctl() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := s.checkSysctl(true, false, SysctlTarget{Key: "kernel.missing"}); got != SysctlErr {
+		t.Errorf("checkSysctl() on missing key = %v, want %v", got, SysctlErr)
+	}
+
+	// Errors only execute the commands without binding, like all other trackers
+	marker := filepath.Join(root, "executed")
+	config.ExecOnError = true
+	config.Commands = []Command{{Command: "touch", Args: []string{marker}, Sysctl: true, Id: 1}}
+	if got := s.checkSysctl(false, false, SysctlTarget{Key: "kernel.missing", CommandId: 1}); got != SysctlExec {
+		t.Errorf("checkSysctl() on missing key with ExecOnError = %v, want %v", got, SysctlExec)
+	}
+	if fileExists(marker) {
+		t.Error("checkSysctl() executed the commands bound to the target on error")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const UnitOk uint8 = 0
+const UnitDown uint8 = 1
+const UnitExec uint8 = 2
+const UnitErr uint8 = 3
+
+// unitState represents the properties of a systemd unit relevant for tracking
+type unitState struct {
+	loadState   string
+	activeState string
+	subState    string
+	result      string
+}
+
+// UnitTracker represents the systemd unit state tracking service
+type UnitTracker struct {
+	Config *Config
+	// down holds the units which were already reported as down
+	down map[string]bool
+	// query returns the state of a unit
+	query func(unit string) (unitState, error)
+}
+
+// NewUnitTracker creates a new UnitTracker instance
+func NewUnitTracker(config *Config) *UnitTracker {
+	u := &UnitTracker{
+		Config: config,
+		down:   make(map[string]bool),
+	}
+	u.query = u.systemctlShow
+	return u
+}
+
+// TrackUnits checks all configured units. Meant to be executed periodically. Returns the number of executions.
+func (u *UnitTracker) TrackUnits(noExec, debug bool) uint {
+	var counter uint = 0
+	for _, target := range u.Config.UnitTrackingConfigs {
+		if u.checkUnit(noExec, debug, target) == UnitExec {
+			counter++
+		}
+	}
+	return counter
+}
+
+// checkUnit queries the unit and retries while it is down. Commands are only executed on the transition to down, not while it stays down.
+func (u *UnitTracker) checkUnit(noExec, debug bool, target UnitTarget) uint8 {
+	var state unitState
+	var err error
+	for i := 0; i <= target.RetryCount; i++ {
+		if debug && i > 0 {
+			u.Config.log("Retrying unit: " + target.Unit)
+		}
+		state, err = u.query(target.Unit)
+		if err != nil {
+			u.Config.logErr(err)
+			if u.Config.ExecOnError {
+				u.Config.exec(debug, CalleeUnit, target.CommandId, noExec)
+				return UnitExec
+			}
+			return UnitErr
+		}
+		if debug {
+			u.Config.log("Unit " + target.Unit + " is " + state.activeState + " (" + state.subState + ")")
+		}
+		if !target.isDown(state) {
+			if u.down[target.Unit] {
+				u.Config.log("Unit recovered: " + target.Unit)
+				delete(u.down, target.Unit)
+			}
+			return UnitOk
+		}
+		if u.down[target.Unit] {
+			return UnitDown
+		}
+		time.Sleep(target.RetryDelay)
+	}
+
+	u.down[target.Unit] = true
+	u.Config.log("Unit down after maximum retries: " + target.Unit + " is " + state.activeState + " (load state: " + state.loadState + ", result: " + state.result + ")")
+	u.Config.execWithEnv(debug, CalleeUnit, target.CommandId, noExec,
+		"GOTRACK_UNIT_NAME="+target.Unit,
+		"GOTRACK_UNIT_STATE="+state.activeState,
+		"GOTRACK_UNIT_RESULT="+state.result,
+	)
+	return UnitExec
+}
+
+// isDown checks if the active state of the unit is one of the configured states, "inactive" and "failed" if none are configured
+func (t UnitTarget) isDown(state unitState) bool {
+	if len(t.States) == 0 {
+		return state.activeState == "inactive" || state.activeState == "failed"
+	}
+	return has(t.States, state.activeState)
+}
+
+// systemctlShow queries the unit using UnitSystemctlCommand
+func (u *UnitTracker) systemctlShow(unit string) (unitState, error) {
+	output, err := exec.Command(u.Config.UnitSystemctlCommand, "show", "--property=LoadState,ActiveState,SubState,Result", "--", unit).Output()
+	if err != nil {
+		return unitState{}, err
+	}
+	return parseUnitState(string(output)), nil
+}
+
+// parseUnitState parses the "key=value" lines of systemctl show
+func parseUnitState(output string) unitState {
+	state := unitState{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "LoadState":
+			state.loadState = value
+		case "ActiveState":
+			state.activeState = value
+		case "SubState":
+			state.subState = value
+		case "Result":
+			state.result = value
+		}
+	}
+	return state
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnitTracker_checkUnit(t *testing.T) {
+	config := NewConfig()
+	config.LogFile = ""
+	config.ExecOnError = false
+	target := UnitTarget{Unit: "nftables.service", RetryCount: 2, CommandId: -1}
+	steps := []struct {
+		name   string
+		states []string
+		want   uint8
+	}{
+		{name: "Active", states: []string{"active"}, want: UnitOk},
+		{name: "Restarted during retries", states: []string{"failed", "active"}, want: UnitOk},
+		{name: "Failed", states: []string{"failed", "failed", "failed"}, want: UnitExec},
+		{name: "Still failed", states: []string{"failed"}, want: UnitDown},
+		{name: "Recovered", states: []string{"active"}, want: UnitOk},
+		{name: "Stopped", states: []string{"inactive", "inactive", "inactive"}, want: UnitExec},
+	}
+
+	u := NewUnitTracker(config)
+	for _, step := range steps {
+		queries := 0
+		u.query = func(unit string) (unitState, error) {
+			if queries >= len(step.states) {
+				t.Fatalf("%s: unexpected query %d", step.name, queries)
+			}
+			state := unitState{activeState: step.states[queries]}
+			queries++
+			return state, nil
+		}
+		if got := u.checkUnit(true, false, target); got != step.want {
+			t.Errorf("%s: checkUnit() = %v, want %v", step.name, got, step.want)
+		}
+		if queries != len(step.states) {
+			t.Errorf("%s: queries = %v, want %v", step.name, queries, len(step.states))
+		}
+	}
+
+	u.query = func(unit string) (unitState, error) {
+		return unitState{}, errors.New("systemctl missing")
+	}
+	if got := u.checkUnit(true, false, target); got != UnitErr {
+		t.Errorf("Error: checkUnit() = %v, want %v", got, UnitErr)
+	}
+	config.ExecOnError = true
+	if got := u.checkUnit(true, false, target); got != UnitExec {
+		t.Errorf("Error with ExecOnError: checkUnit() = %v, want %v", got, UnitExec)
+	}
+}
+
+func TestUnitTarget_isDown(t *testing.T) {
+	tests := []struct {
+		name   string
+		target UnitTarget
+		state  string
+		want   bool
+	}{
+		{name: "Default active", target: UnitTarget{}, state: "active", want: false},
+		{name: "Default failed", target: UnitTarget{}, state: "failed", want: true},
+		{name: "Default inactive", target: UnitTarget{}, state: "inactive", want: true},
+		{name: "Default deactivating", target: UnitTarget{}, state: "deactivating", want: false},
+		{name: "Configured deactivating", target: UnitTarget{States: []string{"deactivating"}}, state: "deactivating", want: true},
+		{name: "Configured failed", target: UnitTarget{States: []string{"deactivating"}}, state: "failed", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.isDown(unitState{activeState: tt.state}); got != tt.want {
+				t.Errorf("isDown() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseUnitState(t *testing.T) {
+	output := "LoadState=loaded\nActiveState=failed\nSubState=failed\nResult=exit-code\n"
+	want := unitState{loadState: "loaded", activeState: "failed", subState: "failed", result: "exit-code"}
+	if got := parseUnitState(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseUnitState() = %v, want %v", got, want)
+	}
+}
@@ -17,7 +17,8 @@ type webCurl struct {
 
 // WebTracker represents the Web tracking service
 type WebTracker struct {
-	Config *Config
+	Config   *Config
+	schedule schedule
 }
 
 const WebNoExec uint8 = 0
@@ -32,16 +33,38 @@ func NewWebTracker(config *Config) *WebTracker {
 	return &WebTracker{Config: config}
 }
 
-// TrackWebSources starts async tracking for Web Pages
+// TrackWebSources starts async tracking for Web Pages that are due. Meant to be executed every TickInterval.
 func (w *WebTracker) TrackWebSources(noExec, debug bool) uint {
 	var counter uint = 0
-	for _, config := range w.Config.WebTrackingConfigs {
+	now := time.Now()
+	tick := w.TickInterval()
+	for i, config := range w.Config.WebTrackingConfigs {
+		if !w.schedule.due(i, now, config.intervalOr(w.Config.WebInterval), tick) {
+			continue
+		}
 		go w.trackWebSource(noExec, debug, config)
 		counter++
 	}
 	return counter
 }
 
+// TickInterval returns the interval TrackWebSources has to be called in, see tickInterval
+func (w *WebTracker) TickInterval() time.Duration {
+	var intervals []time.Duration
+	for _, config := range w.Config.WebTrackingConfigs {
+		intervals = append(intervals, config.intervalOr(w.Config.WebInterval))
+	}
+	return tickInterval(w.Config.WebInterval, intervals...)
+}
+
+// intervalOr returns the interval of the target or the global interval if not set
+func (t WebTarget) intervalOr(global time.Duration) time.Duration {
+	if t.Interval > 0 {
+		return t.Interval
+	}
+	return global
+}
+
 // trackWebSource tracks web sources. Meant to be executed periodically
 func (w *WebTracker) trackWebSource(noExec, debug bool, config WebTarget) uint8 {
 	if debug {